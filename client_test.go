@@ -0,0 +1,342 @@
+package schematic
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+const clientSchema = `{
+	"title": "Platform API",
+	"definitions": {"app": {
+		"type": "object",
+		"definitions": {
+			"id": {"type": "string"},
+			"name": {"type": "string"},
+			"identity": {"$ref": "#/definitions/app/definitions/id"}
+		},
+		"properties": {
+			"id": {"$ref": "#/definitions/app/definitions/id"},
+			"name": {"$ref": "#/definitions/app/definitions/name"},
+			"owner": {"oneOf": [
+				{"title": "User", "type": "object", "properties": {"email": {"type": "string"}}},
+				{"title": "Team", "type": "object", "properties": {"name": {"type": "string"}, "members": {"type": "integer"}}}
+			]}
+		},
+		"links": [
+			{"title": "Info", "rel": "self", "method": "GET", "href": "/apps/{(%23%2Fdefinitions%2Fapp%2Fdefinitions%2Fidentity)}"},
+			{"title": "List", "rel": "instances", "method": "GET", "href": "/apps",
+				"targetSchema": {"type": "array", "items": {"$ref": "#/definitions/app"}}},
+			{"title": "Create", "rel": "create", "method": "POST", "href": "/apps", "schema": {
+				"type": "object",
+				"properties": {"name": {"$ref": "#/definitions/app/definitions/name"}}
+			}}
+		]
+	}},
+	"properties": {"app": {"$ref": "#/definitions/app"}}
+}`
+
+// testClient generates the client of clientSchema in a module of its own,
+// along with the given tests of package api, and runs them. It is skipped
+// when the go command or the dependencies of the client aren't available.
+func testClient(t *testing.T, tests string) {
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	var s Schema
+	if err := json.Unmarshal([]byte(clientSchema), &s); err != nil {
+		t.Fatal(err)
+	}
+	src, err := s.GenerateWithOptions(Options{Package: "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":      "module api\n\ngo 1.20\n\nrequire github.com/google/go-querystring v1.1.0\n",
+		"api.go":      string(src),
+		"api_test.go": tests,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := func(args ...string) ([]byte, error) {
+		cmd := exec.Command(goCmd, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GO111MODULE=on", "GOFLAGS=-mod=mod")
+		return cmd.CombinedOutput()
+	}
+	if out, err := run("mod", "download", "github.com/google/go-querystring"); err != nil {
+		t.Skipf("client dependencies unavailable: %s", out)
+	}
+	if out, err := run("test", "."); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+}
+
+func TestClientListAll(t *testing.T) {
+	testClient(t, `package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestListAll(t *testing.T) {
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		n := len(ranges)
+		if n < 3 {
+			w.Header().Set("Next-Range", fmt.Sprintf("name ]a%d..; max=2", n))
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		fmt.Fprintf(w, "[{\"name\":\"a%d\"},{\"name\":\"b%d\"}]", n, n)
+	}))
+	defer srv.Close()
+	s := NewService(nil)
+	s.URL = srv.URL
+
+	it := s.AppListAll(context.Background(), &ListRange{Field: "name", Max: 2})
+	var names []string
+	for it.Next() {
+		names = append(names, it.Value().Name)
+	}
+	if it.Err() != nil {
+		t.Fatal(it.Err())
+	}
+	if want := []string{"a1", "b1", "a2", "b2", "a3", "b3"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("wants %v, got %v", want, names)
+	}
+	if want := []string{"name ..; max=2", "name ]a1..; max=2", "name ]a2..; max=2"}; !reflect.DeepEqual(ranges, want) {
+		t.Errorf("wants ranges %v, got %v", want, ranges)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	it = s.AppListAll(ctx, nil)
+	it.Next()
+	cancel()
+	if it.Next() || it.Err() != context.Canceled {
+		t.Errorf("wants the iteration to stop with %v, got %v", context.Canceled, it.Err())
+	}
+}
+`)
+}
+
+func TestClientUnions(t *testing.T) {
+	testClient(t, `package api
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUnions(t *testing.T) {
+	var app App
+	if err := json.Unmarshal([]byte("{\"owner\": {\"name\": \"a\", \"members\": 2}}"), &app); err != nil {
+		t.Fatal(err)
+	}
+	if app.Owner.Team == nil || app.Owner.User != nil {
+		t.Errorf("wants the team branch, got %+v", app.Owner)
+	}
+	if err := json.Unmarshal([]byte("{\"owner\": {\"email\": \"a@example.com\", \"since\": 2020}}"), &app); err != nil {
+		t.Fatal(err)
+	}
+	if app.Owner.User == nil || app.Owner.User.Email == nil || *app.Owner.User.Email != "a@example.com" {
+		t.Errorf("wants the user branch despite the unknown field, got %+v", app.Owner)
+	}
+}
+`)
+}
+
+func TestClientRetry(t *testing.T) {
+	testClient(t, `package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("{\"name\":\"a1\"}"))
+	}))
+	defer srv.Close()
+	s := NewService(nil)
+	s.URL = srv.URL
+	s.Retry = &Backoff{Delay: time.Millisecond}
+
+	if _, err := s.AppInfo(context.Background(), "a1"); err != nil || attempts != 3 {
+		t.Errorf("wants 3 attempts, got %d: %v", attempts, err)
+	}
+
+	atomic.StoreInt32(&attempts, 0)
+	if _, err := s.AppCreate(context.Background(), AppCreateOpts{}); err == nil || attempts != 1 {
+		t.Errorf("wants POST not to be retried, got %d attempts", attempts)
+	}
+
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	s.Retry = &Backoff{Delay: time.Hour, MaxAttempts: 1000}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := s.AppInfo(ctx, "a1"); err != context.DeadlineExceeded {
+		t.Errorf("wants %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestRetryPolicyFunc(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("{\"name\":\"a1\"}"))
+	}))
+	defer srv.Close()
+	s := NewService(nil)
+	s.URL = srv.URL
+	s.Retry = RetryPolicyFunc(func(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+		return 0, req.Method == "POST" && attempt < 2
+	})
+
+	if _, err := s.AppCreate(context.Background(), AppCreateOpts{}); err != nil || attempts != 2 {
+		t.Errorf("wants 2 attempts, got %d: %v", attempts, err)
+	}
+}
+`)
+}
+
+func TestClientRateLimit(t *testing.T) {
+	testClient(t, `package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("RateLimit-Remaining", "0")
+		w.Write([]byte("{\"name\":\"a1\"}"))
+	}))
+	defer srv.Close()
+	s := NewService(nil)
+	s.URL = srv.URL
+	s.Limiter = NewLimiter(20, 10)
+
+	if !s.RateLimit().Time.IsZero() {
+		t.Errorf("wants no rate limit observed, got %+v", s.RateLimit())
+	}
+	if _, err := s.AppInfo(context.Background(), "a1"); err != nil {
+		t.Fatal(err)
+	}
+	if rl := s.RateLimit(); rl.Remaining != 0 || rl.Time.IsZero() {
+		t.Errorf("wants no remaining requests observed, got %+v", rl)
+	}
+
+	start := time.Now()
+	if _, err := s.AppInfo(context.Background(), "a1"); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 30*time.Millisecond {
+		t.Errorf("wants the request throttled, got it sent after %v", d)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, err := s.AppInfo(ctx, "a1"); err != context.DeadlineExceeded {
+		t.Errorf("wants %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+`)
+}
+
+func TestClientMiddleware(t *testing.T) {
+	testClient(t, `package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/apps" {
+			w.Write([]byte("[]"))
+			return
+		}
+		w.Write([]byte("{\"name\":\"a1\"}"))
+	}))
+	defer srv.Close()
+	s := NewService(nil)
+	s.URL = srv.URL
+	var calls []string
+	s.Use(func(method string, req *http.Request, next Doer) (*http.Response, error) {
+		calls = append(calls, "outer:"+method)
+		req.Header.Set("Authorization", "Bearer token")
+		resp, err := next(req)
+		if err == nil {
+			calls = append(calls, "status:"+resp.Status)
+		}
+		return resp, err
+	}, func(method string, req *http.Request, next Doer) (*http.Response, error) {
+		calls = append(calls, "inner:"+method)
+		return next(req)
+	})
+
+	if _, err := s.AppInfo(context.Background(), "a1"); err != nil {
+		t.Fatal(err)
+	}
+	it := s.AppListAll(context.Background(), nil)
+	for it.Next() {
+	}
+	if it.Err() != nil {
+		t.Fatal(it.Err())
+	}
+	if err := s.Get(context.Background(), nil, "/", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"outer:AppInfo", "inner:AppInfo", "status:200 OK",
+		"outer:AppList", "inner:AppList", "status:200 OK",
+		"outer:", "inner:", "status:200 OK",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("wants %v, got %v", want, calls)
+	}
+}
+`)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/interagent/schematic"
+)
+
+// diff reports the changes between the two schemas given as arguments,
+// exiting with a non-zero code if some break the generated client. With -go,
+// the exported Go APIs of the generated clients are compared instead, both
+// generated with the options set by the flags preceding the command.
+func diff(args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := flags.String("format", "text", "Output format, text or json")
+	goAPI := flags.Bool("go", false, "Compare the Go APIs of the generated clients")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: schematic [generation flags] diff [flags] old.json new.json")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 || (*format != "text" && *format != "json") {
+		flags.Usage()
+		return 2
+	}
+
+	from, err := load(flags.Arg(0))
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+	to, err := load(flags.Arg(1))
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+	var changes []fmt.Stringer
+	breaking := false
+	if *goAPI {
+		cs, err := schematic.CompareAPI(from, to, options())
+		if err != nil {
+			log.Print(err)
+			return 2
+		}
+		for _, c := range cs {
+			changes = append(changes, c)
+			breaking = breaking || c.Breaking
+		}
+	} else {
+		cs, err := schematic.Diff(from, to)
+		if err != nil {
+			log.Print(err)
+			return 2
+		}
+		for _, c := range cs {
+			changes = append(changes, c)
+			breaking = breaking || c.Breaking
+		}
+	}
+
+	if *format == "json" {
+		if changes == nil {
+			changes = []fmt.Stringer{}
+		}
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		if err := e.Encode(changes); err != nil {
+			log.Print(err)
+			return 2
+		}
+	} else {
+		for _, c := range changes {
+			fmt.Fprintln(os.Stdout, c)
+		}
+	}
+	if breaking {
+		return 1
+	}
+	return 0
+}
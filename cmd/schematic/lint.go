@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/interagent/schematic"
+)
+
+// lint reports the violations of the design rules by the schema given as
+// argument, exiting with a non-zero code if some aren't warnings.
+func lint(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	rules := flags.String("rules", "", "Comma-separated rules to check, all by default")
+	disable := flags.String("disable", "", "Comma-separated rules not to check")
+	format := flags.String("format", "text", "Output format, text or json")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: schematic lint [flags] schema.json")
+		flags.PrintDefaults()
+		fmt.Fprintln(flags.Output(), "rules:")
+		for _, r := range schematic.LintRules() {
+			fmt.Fprintf(flags.Output(), "  %s: %s\n", r.Name, r.Description)
+		}
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 || (*format != "text" && *format != "json") {
+		flags.Usage()
+		return 2
+	}
+
+	var enabled []string
+	if *rules != "" {
+		enabled = strings.Split(*rules, ",")
+	}
+	if *disable != "" {
+		if enabled == nil {
+			for _, r := range schematic.LintRules() {
+				enabled = append(enabled, r.Name)
+			}
+		}
+		known := make(map[string]bool)
+		for _, r := range schematic.LintRules() {
+			known[r.Name] = true
+		}
+		disabled := make(map[string]bool)
+		for _, name := range strings.Split(*disable, ",") {
+			if !known[name] {
+				log.Printf("unknown lint rule %q", name)
+				return 2
+			}
+			disabled[name] = true
+		}
+		var kept []string
+		for _, name := range enabled {
+			if !disabled[name] {
+				kept = append(kept, name)
+			}
+		}
+		if len(kept) == 0 {
+			return 0
+		}
+		enabled = kept
+	}
+
+	s, err := load(flags.Arg(0))
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	problems, err := s.Lint(enabled...)
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+
+	if *format == "json" {
+		if problems == nil {
+			problems = []*schematic.LintProblem{}
+		}
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		if err := e.Encode(problems); err != nil {
+			log.Print(err)
+			return 1
+		}
+	} else {
+		for _, p := range problems {
+			fmt.Fprintln(os.Stdout, p)
+		}
+	}
+	for _, p := range problems {
+		if !p.Warning {
+			return 1
+		}
+	}
+	return 0
+}
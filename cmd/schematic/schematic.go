@@ -12,6 +12,15 @@
 //     package heroku
 //     ...
 //
+// Or check your schema first:
+//
+//     $ schematic validate platform-api.json
+//     $ schematic lint platform-api.json
+//
+// And compare it with its previous version:
+//
+//     $ schematic diff old-platform-api.json platform-api.json
+//
 package main
 
 import (
@@ -21,11 +30,32 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/interagent/schematic"
 )
 
-var output = flag.String("o", "", "Output file")
+var (
+	output       = flag.String("o", "", "Output file")
+	dir          = flag.String("d", "", "Output directory, writing service.go and a file per resource")
+	pkg          = flag.String("package", "", "Package name, defaults to the first word of the schema title")
+	service      = flag.String("service", "", "Service type name, defaults to Service")
+	baseURL      = flag.String("url", "", "Default base URL, overriding the schema self link")
+	userAgent    = flag.String("user-agent", "", "User-Agent prefix, defaults to the package name")
+	pruneImports = flag.Bool("prune-imports", false, "Only import packages used by the generated code")
+	fake         = flag.Bool("fake", false, "Generate an in-memory fake implementing the service interface")
+	server       = flag.Bool("server", false, "Generate a handler interface and a router serving the API")
+	mock         = flag.Bool("mock", false, "Generate a package serving a fake API from the schema examples, instead of the client")
+	templates    = flag.String("templates", "", "Directory of templates overriding the bundled ones")
+	number       = flag.String("number", "", "Go type of numbers instead of float64, e.g. encoding/json.Number")
+	formats      = formatTypes{}
+)
+
+func init() {
+	flag.Var(formats, "format-type", "Go type of a string format, e.g. uuid=github.com/google/uuid.UUID, repeatable")
+}
 
 func main() {
 	defer func() {
@@ -39,18 +69,33 @@ func main() {
 
 	flag.Parse()
 
+	if flag.NArg() > 0 {
+		if command, ok := commands[flag.Arg(0)]; ok {
+			os.Exit(command(flag.Args()[1:]))
+		}
+	}
 	if flag.NArg() != 1 {
 		log.Fatal("missing schema file")
 	}
 
-	var i io.Reader
-	var err error
-	if flag.Arg(0) == "-" {
-		i = os.Stdin
-	} else {
-		if i, err = os.Open(flag.Arg(0)); err != nil {
+	s, err := load(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	opts := options()
+
+	if *dir != "" {
+		if *mock {
+			log.Fatal("-mock and -d are mutually exclusive")
+		}
+		if *output != "" {
+			log.Fatal("-o and -d are mutually exclusive")
+		}
+		if err := writeFiles(s, opts, *dir); err != nil {
 			log.Fatal(err)
 		}
+		return
 	}
 
 	var o io.Writer
@@ -62,17 +107,100 @@ func main() {
 		}
 	}
 
-	var s schematic.Schema
-	d := json.NewDecoder(i)
-	if err := d.Decode(&s); err != nil {
-		log.Fatal(err)
+	generate := s.GenerateWithOptions
+	if *mock {
+		generate = s.GenerateMock
 	}
-
-	code, err := s.Generate()
+	code, err := generate(opts)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%s\n", code)
+		if code != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", code)
+		}
 		log.Fatal(err)
 	}
 
 	fmt.Fprintln(o, string(code))
 }
+
+// options returns the generation options set by the flags.
+func options() schematic.Options {
+	opts := schematic.Options{
+		Package:      *pkg,
+		Service:      *service,
+		URL:          *baseURL,
+		UserAgent:    *userAgent,
+		PruneImports: *pruneImports,
+		Fake:         *fake,
+		Server:       *server,
+		Templates:    *templates,
+		Formats:      formats,
+	}
+	if *number != "" {
+		opts.Number = schematic.ParseFormatType(*number)
+	}
+	return opts
+}
+
+// formatTypes maps string formats to the Go types set with -format-type.
+type formatTypes map[string]schematic.FormatType
+
+func (f formatTypes) String() string {
+	var s []string
+	for format, t := range f {
+		s = append(s, format+"="+t.Type)
+	}
+	sort.Strings(s)
+	return strings.Join(s, ",")
+}
+
+func (f formatTypes) Set(v string) error {
+	i := strings.Index(v, "=")
+	if i <= 0 || i == len(v)-1 {
+		return fmt.Errorf("%q is not a format=type pair", v)
+	}
+	f[v[:i]] = schematic.ParseFormatType(v[i+1:])
+	return nil
+}
+
+// commands maps subcommands to the functions running them with their
+// arguments and returning the exit code.
+var commands = map[string]func(args []string) int{
+	"validate": validate,
+	"lint":     lint,
+	"diff":     diff,
+}
+
+// load loads the schema found at path, or read from stdin if path is "-".
+func load(path string) (*schematic.Schema, error) {
+	if path != "-" {
+		return schematic.Load(path, schematic.FileLoader{})
+	}
+	s := new(schematic.Schema)
+	if err := json.NewDecoder(os.Stdin).Decode(s); err != nil {
+		return nil, err
+	}
+	s.SetLoader(path, schematic.FileLoader{})
+	return s, nil
+}
+
+// writeFiles generates the code split per resource into dir.
+func writeFiles(s *schematic.Schema, opts schematic.Options, dir string) error {
+	files, err := s.GenerateFiles(opts)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), files[name], 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+// validate reports the problems of the schema given as argument, exiting
+// with a non-zero code if there are some.
+func validate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: schematic validate schema.json")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	s, err := load(flags.Arg(0))
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	problems := s.Validate()
+	for _, p := range problems {
+		fmt.Fprintln(os.Stdout, p)
+	}
+	if len(problems) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,287 @@
+package schematic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// APIChange is a difference between the exported Go APIs of two generated
+// packages.
+type APIChange struct {
+	// Name is the changed identifier, e.g. "App.Name" for a field or
+	// method.
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	// Breaking is true for changes breaking the code using the previous
+	// version of the package.
+	Breaking bool `json:"breaking"`
+}
+
+func (c *APIChange) String() string {
+	msg := fmt.Sprintf("%s: %s", c.Name, c.Message)
+	if c.Breaking {
+		msg = "breaking: " + msg
+	}
+	return msg
+}
+
+// CompareAPI generates the code of two schemas with the same options, and
+// returns the changes of the exported Go API between them: identifiers,
+// function and method signatures, and struct fields. It catches the changes
+// brought by naming rules as well as by the schemas.
+func CompareAPI(from, to *Schema, opts Options) ([]*APIChange, error) {
+	fset := token.NewFileSet()
+	imp := newStubImporter(opts)
+	a, err := from.typeCheck(fset, imp, opts)
+	if err != nil {
+		return nil, err
+	}
+	b, err := to.typeCheck(fset, imp, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &comparer{from: a, to: b}
+	for _, name := range a.Scope().Names() {
+		o := a.Scope().Lookup(name)
+		if !o.Exported() {
+			continue
+		}
+		n := b.Scope().Lookup(name)
+		if n == nil {
+			c.report(name, true, "removed")
+			continue
+		}
+		c.object(name, o, n)
+	}
+	for _, name := range b.Scope().Names() {
+		if n := b.Scope().Lookup(name); n.Exported() && a.Scope().Lookup(name) == nil {
+			c.report(name, false, "added")
+		}
+	}
+	return c.changes, nil
+}
+
+// typeCheck generates the code of the schema and type-checks its
+// declarations. The standard library is imported with the go command, while
+// other packages are stubbed, declaring the types set by the options.
+func (s *Schema) typeCheck(fset *token.FileSet, imp types.Importer, opts Options) (*types.Package, error) {
+	src, err := s.GenerateWithOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		return nil, err
+	}
+	conf := types.Config{
+		Importer:         imp,
+		IgnoreFuncBodies: true,
+	}
+	pkg, err := conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("type-checking the generated code: %v", err)
+	}
+	return pkg, nil
+}
+
+// stubImporter imports the standard library, and stubs of other packages
+// declaring the opaque types set by the options.
+type stubImporter struct {
+	std   types.Importer
+	types map[string][]string
+}
+
+func newStubImporter(opts Options) *stubImporter {
+	i := &stubImporter{std: importer.Default(), types: make(map[string][]string)}
+	fts := []FormatType{opts.Number}
+	for _, ft := range opts.Formats {
+		fts = append(fts, ft)
+	}
+	for _, ft := range fts {
+		if ft.Import != "" {
+			name := ft.Type[strings.LastIndex(ft.Type, ".")+1:]
+			i.types[ft.Import] = append(i.types[ft.Import], name)
+		}
+	}
+	return i
+}
+
+func (i *stubImporter) Import(p string) (*types.Package, error) {
+	if pkg, err := i.std.Import(p); err == nil {
+		return pkg, nil
+	}
+	pkg := types.NewPackage(p, importName(p))
+	for _, name := range i.types[p] {
+		if pkg.Scope().Lookup(name) != nil {
+			continue
+		}
+		tn := types.NewTypeName(token.NoPos, pkg, name, nil)
+		types.NewNamed(tn, types.NewStruct(nil, nil), nil)
+		pkg.Scope().Insert(tn)
+	}
+	pkg.MarkComplete()
+	return pkg, nil
+}
+
+type comparer struct {
+	from, to *types.Package
+	changes  []*APIChange
+}
+
+func (c *comparer) report(name string, breaking bool, format string, a ...interface{}) {
+	c.changes = append(c.changes, &APIChange{
+		Name:     name,
+		Message:  fmt.Sprintf(format, a...),
+		Breaking: breaking,
+	})
+}
+
+// typeString formats a type of either package, the types of the package
+// itself being unqualified.
+func (c *comparer) typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == c.from || p == c.to {
+			return ""
+		}
+		return p.Name()
+	})
+}
+
+func (c *comparer) object(name string, o, n types.Object) {
+	switch o := o.(type) {
+	case *types.TypeName:
+		nt, ok := n.(*types.TypeName)
+		if !ok {
+			c.report(name, true, "changed from a type to a %s", kind(n))
+			return
+		}
+		c.typ(name, o.Type(), nt.Type())
+	default:
+		if kind(o) != kind(n) {
+			c.report(name, true, "changed from a %s to a %s", kind(o), kind(n))
+			return
+		}
+		if ot, nt := c.typeString(o.Type()), c.typeString(n.Type()); ot != nt {
+			c.report(name, true, "changed from %s to %s", ot, nt)
+		}
+	}
+}
+
+func (c *comparer) typ(name string, o, n types.Type) {
+	ou, nu := o.Underlying(), n.Underlying()
+	switch ou := ou.(type) {
+	case *types.Struct:
+		nu, ok := nu.(*types.Struct)
+		if !ok {
+			c.report(name, true, "changed from a struct to %s", c.typeString(n.Underlying()))
+			return
+		}
+		c.fields(name, ou, nu)
+	case *types.Interface:
+		nu, ok := nu.(*types.Interface)
+		if !ok {
+			c.report(name, true, "changed from an interface to %s", c.typeString(n.Underlying()))
+			return
+		}
+		// Implementations break when methods are added as well.
+		c.methods(name, methods(ou), methods(nu), true)
+		return
+	default:
+		if ot, nt := c.typeString(ou), c.typeString(nu); ot != nt {
+			c.report(name, true, "changed from %s to %s", ot, nt)
+		}
+	}
+	c.methods(name, methods(types.NewPointer(o)), methods(types.NewPointer(n)), false)
+}
+
+func (c *comparer) fields(name string, o, n *types.Struct) {
+	nf := make(map[string]*types.Var)
+	for i := 0; i < n.NumFields(); i++ {
+		nf[n.Field(i).Name()] = n.Field(i)
+	}
+	of := make(map[string]bool)
+	for i := 0; i < o.NumFields(); i++ {
+		f := o.Field(i)
+		if !f.Exported() {
+			continue
+		}
+		of[f.Name()] = true
+		fn := name + "." + f.Name()
+		g, ok := nf[f.Name()]
+		if !ok {
+			c.report(fn, true, "field removed")
+			continue
+		}
+		if ot, nt := c.typeString(f.Type()), c.typeString(g.Type()); ot != nt {
+			c.report(fn, true, "field changed from %s to %s", ot, nt)
+		}
+	}
+	for i := 0; i < n.NumFields(); i++ {
+		if f := n.Field(i); f.Exported() && !of[f.Name()] {
+			c.report(name+"."+f.Name(), false, "field added")
+		}
+	}
+}
+
+// methods compares the exported methods of a type. Added methods break
+// interfaces.
+func (c *comparer) methods(name string, o, n map[string]*types.Func, iface bool) {
+	for _, m := range sortedFuncs(o) {
+		mn := name + "." + m
+		g, ok := n[m]
+		if !ok {
+			c.report(mn, true, "method removed")
+			continue
+		}
+		if ot, nt := c.typeString(o[m].Type()), c.typeString(g.Type()); ot != nt {
+			c.report(mn, true, "method changed from %s to %s", ot, nt)
+		}
+	}
+	for _, m := range sortedFuncs(n) {
+		if _, ok := o[m]; !ok {
+			c.report(name+"."+m, iface, "method added")
+		}
+	}
+}
+
+// methods returns the exported methods of the method set of t.
+func methods(t types.Type) map[string]*types.Func {
+	m := make(map[string]*types.Func)
+	ms := types.NewMethodSet(t)
+	for i := 0; i < ms.Len(); i++ {
+		if f, ok := ms.At(i).Obj().(*types.Func); ok && f.Exported() {
+			m[f.Name()] = f
+		}
+	}
+	return m
+}
+
+func sortedFuncs(m map[string]*types.Func) (names []string) {
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// kind names the kind of an object.
+func kind(o types.Object) string {
+	switch o.(type) {
+	case *types.Const:
+		return "constant"
+	case *types.Var:
+		return "variable"
+	case *types.Func:
+		return "function"
+	case *types.TypeName:
+		return "type"
+	}
+	return "object"
+}
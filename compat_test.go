@@ -0,0 +1,86 @@
+package schematic
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var compareAPITests = []struct {
+	Patch   func(s *Schema)
+	Changes []string
+}{
+	{
+		Patch:   func(s *Schema) {},
+		Changes: nil,
+	},
+	{
+		Patch: func(s *Schema) {
+			app := s.Definitions["app"]
+			app.Links[1].Title = "Make"
+			app.Properties["size"].Type = "string"
+			app.Properties["stack"] = &Schema{Type: "string"}
+		},
+		Changes: []string{
+			"breaking: App.Size: field changed from int to string",
+			"App.Stack: field added",
+			"breaking: AppCreateOpts: removed",
+			"breaking: Service.AppCreate: method removed",
+			"Service.AppMake: method added",
+			"breaking: ServiceInterface.AppCreate: method removed",
+			"breaking: ServiceInterface.AppMake: method added",
+			"AppMakeOpts: added",
+		},
+	},
+	{
+		Patch: func(s *Schema) {
+			s.Definitions["app"].Required = []string{"name"}
+			s.Definitions["app"].Definitions["name"].Type = []interface{}{"string", "null"}
+		},
+		Changes: []string{
+			"breaking: App.Name: field changed from string to *string",
+		},
+	},
+	{
+		Patch: func(s *Schema) {
+			s.Definitions["app"].Definitions["id"].Format = "ulid"
+		},
+		Changes: []string{
+			"breaking: App.ID: field changed from uuid.UUID to ulid.ULID",
+			"breaking: Service.AppInfo: method changed from func(ctx context.Context, appIdentity uuid.UUID) (*App, error) to func(ctx context.Context, appIdentity ulid.ULID) (*App, error)",
+			"breaking: ServiceInterface.AppInfo: method changed from func(ctx context.Context, appIdentity uuid.UUID) (*App, error) to func(ctx context.Context, appIdentity ulid.ULID) (*App, error)",
+		},
+	},
+}
+
+func TestCompareAPI(t *testing.T) {
+	for i, ct := range compareAPITests {
+		var from, to Schema
+		if err := json.Unmarshal([]byte(diffBase), &from); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal([]byte(diffBase), &to); err != nil {
+			t.Fatal(err)
+		}
+		from.Definitions["app"].Definitions["id"].Format = "uuid"
+		to.Definitions["app"].Definitions["id"].Format = "uuid"
+		ct.Patch(&to)
+		changes, err := CompareAPI(&from, &to, Options{
+			Package: "api",
+			Formats: map[string]FormatType{
+				"uuid": ParseFormatType("github.com/google/uuid.UUID"),
+				"ulid": ParseFormatType("github.com/oklog/ulid/v2.ULID"),
+			},
+		})
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		var found []string
+		for _, c := range changes {
+			found = append(found, c.String())
+		}
+		if !reflect.DeepEqual(found, ct.Changes) {
+			t.Errorf("%d: wants %v, got %v", i, ct.Changes, found)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package schematic
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Change is a difference between two versions of a schema.
+type Change struct {
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+	// Breaking is true for changes breaking the clients generated from the
+	// previous version.
+	Breaking bool `json:"breaking"`
+}
+
+func (c *Change) String() string {
+	msg := fmt.Sprintf("%s: %s", c.Pointer, c.Message)
+	if c.Breaking {
+		msg = "breaking: " + msg
+	}
+	return msg
+}
+
+// Diff compares the resolved versions of two schemas and returns the
+// changes between them, resource by resource. Pointers follow the resource
+// properties, e.g. "#/properties/app/links/0", links being numbered as in
+// the new version, or in the old one once removed.
+func Diff(from, to *Schema) ([]*Change, error) {
+	from, err := from.Resolve(nil, ResolvedSet{})
+	if err != nil {
+		return nil, err
+	}
+	to, err = to.Resolve(nil, ResolvedSet{})
+	if err != nil {
+		return nil, err
+	}
+
+	d := &differ{seen: make(map[visit]bool)}
+	for _, name := range unionKeys(from.Properties, to.Properties) {
+		o, n := from.Properties[name], to.Properties[name]
+		pointer := pointerTo(fragment, "properties", name)
+		switch {
+		case n == nil:
+			d.report(pointer, true, "resource %s removed", name)
+		case o == nil:
+			d.report(pointer, false, "resource %s added", name)
+		default:
+			d.schema(o, n, pointer, false)
+			d.links(name, o, n, pointer)
+		}
+	}
+	return d.changes, nil
+}
+
+type differ struct {
+	seen    map[visit]bool
+	changes []*Change
+}
+
+// visit is a pair of schemas compared, in request schemas or not.
+type visit struct {
+	o, n    *Schema
+	request bool
+}
+
+func (d *differ) report(pointer string, breaking bool, format string, a ...interface{}) {
+	d.changes = append(d.changes, &Change{
+		Pointer:  pointer,
+		Message:  fmt.Sprintf(format, a...),
+		Breaking: breaking,
+	})
+}
+
+// schema compares two versions of a schema, found in the request schema of a
+// link or not. Newly required properties only break requests, which clients
+// have to fill.
+func (d *differ) schema(o, n *Schema, pointer string, request bool) {
+	switch {
+	case o == nil && n == nil:
+		return
+	case n == nil:
+		d.report(pointer, true, "schema removed")
+		return
+	case o == nil:
+		d.report(pointer, true, "schema added")
+		return
+	}
+	if d.seen[visit{o, n, request}] {
+		return
+	}
+	d.seen[visit{o, n, request}] = true
+
+	if ot, nt := describeType(o), describeType(n); ot != nt {
+		d.report(pointer, true, "type changed from %s to %s", ot, nt)
+		return
+	}
+	for _, v := range o.Enum {
+		if !contains(v, n.Enum) {
+			d.report(pointer, true, "enum value %q removed", v)
+		}
+	}
+	for _, v := range n.Enum {
+		if len(o.Enum) > 0 && !contains(v, o.Enum) {
+			d.report(pointer, false, "enum value %q added", v)
+		}
+	}
+	for _, name := range n.Required {
+		if !contains(name, o.Required) {
+			d.report(pointerTo(pointer, "properties", name), request, "property %s is now required", name)
+		}
+	}
+	for _, name := range unionKeys(o.Properties, n.Properties) {
+		op, np := o.Properties[name], n.Properties[name]
+		pp := pointerTo(pointer, "properties", name)
+		switch {
+		case np == nil:
+			d.report(pp, true, "property %s removed", name)
+		case op == nil:
+			d.report(pp, false, "property %s added", name)
+		default:
+			d.schema(op, np, pp, request)
+		}
+	}
+	if o.Items != nil || n.Items != nil {
+		d.schema(o.Items, n.Items, pointerTo(pointer, "items"), request)
+	}
+	if o.IsUnion() && n.IsUnion() {
+		ob, nb := o.allBranches(), n.allBranches()
+		for i := range ob {
+			bp := pointerTo(pointer, n.branchesKeyword(), strconv.Itoa(i))
+			if i >= len(nb) {
+				d.report(bp, true, "branch %d removed", i)
+				continue
+			}
+			d.schema(ob[i], nb[i], bp, request)
+		}
+		for i := len(ob); i < len(nb); i++ {
+			d.report(pointerTo(pointer, n.branchesKeyword(), strconv.Itoa(i)), false, "branch %d added", i)
+		}
+	}
+}
+
+// links compares the links of two versions of the named resource, matched
+// by title.
+func (d *differ) links(name string, o, n *Schema, pointer string) {
+	index := func(s *Schema) map[string]int {
+		m := make(map[string]int)
+		for i, l := range s.Links {
+			m[strings.ToLower(l.Title)] = i
+		}
+		return m
+	}
+	oi, ni := index(o), index(n)
+	for i, ol := range o.Links {
+		if _, ok := ni[strings.ToLower(ol.Title)]; !ok {
+			d.report(pointerTo(pointer, "links", strconv.Itoa(i)), true, "link %q removed", ol.Title)
+		}
+	}
+	for i, nl := range n.Links {
+		lp := pointerTo(pointer, "links", strconv.Itoa(i))
+		j, ok := oi[strings.ToLower(nl.Title)]
+		if !ok {
+			d.report(lp, false, "link %q added", nl.Title)
+			continue
+		}
+		ol := o.Links[j]
+		if !strings.EqualFold(ol.Method, nl.Method) {
+			d.report(lp, true, "method changed from %s to %s", ol.Method, nl.Method)
+		}
+		if ol.HRef != nil && nl.HRef != nil {
+			if !equalStrings(ol.HRef.Order, nl.HRef.Order) {
+				d.report(pointerTo(lp, "href"), true, "href variables changed from %v to %v", ol.HRef.Order, nl.HRef.Order)
+			} else {
+				for _, v := range nl.HRef.Order {
+					d.schema(ol.HRef.Schemas[v], nl.HRef.Schemas[v], pointerTo(lp, "href"), false)
+				}
+			}
+		}
+		if ol.Paginated() && !nl.Paginated() {
+			d.report(lp, true, "link is no longer paginated")
+		}
+		if ot, nt := returnType(name, o, ol), returnType(name, n, nl); ot != nt {
+			d.report(pointerTo(lp, "targetSchema"), true, "result type renamed from %s to %s", ot, nt)
+		}
+		d.schema(ol.Schema, nl.Schema, pointerTo(lp, "schema"), true)
+		if ol.TargetSchema != nil && nl.TargetSchema != nil {
+			d.schema(ol.TargetSchema, nl.TargetSchema, pointerTo(lp, "targetSchema"), false)
+		}
+	}
+}
+
+// describeType describes the JSON types of a schema, with its format.
+func describeType(s *Schema) string {
+	if s.IsUnion() {
+		return s.branchesKeyword()
+	}
+	types, _ := s.Types()
+	types = append([]string(nil), types...)
+	sort.Strings(types)
+	t := strings.Join(types, "|")
+	if s.Format != "" {
+		t += " (" + s.Format + ")"
+	}
+	return t
+}
+
+func unionKeys(a, b map[string]*Schema) []string {
+	keys := sortedKeys(a)
+	for _, k := range sortedKeys(b) {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,118 @@
+package schematic
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const diffBase = `{
+	"definitions": {"app": {
+		"type": "object",
+		"definitions": {
+			"id": {"type": "string"},
+			"name": {"type": "string"},
+			"identity": {"$ref": "#/definitions/app/definitions/id"}
+		},
+		"properties": {
+			"id": {"$ref": "#/definitions/app/definitions/id"},
+			"name": {"$ref": "#/definitions/app/definitions/name"},
+			"size": {"type": "integer"}
+		},
+		"links": [
+			{"title": "Info", "method": "GET", "href": "/apps/{(%23%2Fdefinitions%2Fapp%2Fdefinitions%2Fidentity)}"},
+			{"title": "Create", "method": "POST", "href": "/apps", "schema": {
+				"type": "object",
+				"properties": {"name": {"$ref": "#/definitions/app/definitions/name"}}
+			}}
+		]
+	}},
+	"properties": {"app": {"$ref": "#/definitions/app"}}
+}`
+
+var diffTests = []struct {
+	Patch   func(s *Schema)
+	Changes []string
+}{
+	{
+		Patch:   func(s *Schema) {},
+		Changes: nil,
+	},
+	{
+		Patch: func(s *Schema) {
+			s.Definitions["app"].Properties["stack"] = &Schema{Type: "string"}
+			s.Definitions["app"].Links = append(s.Definitions["app"].Links, &Link{Title: "List", Method: "GET", HRef: NewHRef("/apps")})
+		},
+		Changes: []string{
+			"#/properties/app/properties/stack",
+			"#/properties/app/links/2",
+		},
+	},
+	{
+		Patch: func(s *Schema) {
+			app := s.Definitions["app"]
+			delete(app.Properties, "name")
+			app.Properties["size"].Type = "string"
+			app.Links = app.Links[1:]
+			app.Links[0].Schema.Required = []string{"name"}
+		},
+		Changes: []string{
+			"breaking #/properties/app/properties/name",
+			"breaking #/properties/app/properties/size",
+			"breaking #/properties/app/links/0",
+			"breaking #/properties/app/links/0/schema/properties/name",
+		},
+	},
+	{
+		Patch: func(s *Schema) {
+			s.Definitions["app"].Links[0].HRef = NewHRef("/apps/{(%23%2Fdefinitions%2Fapp%2Fdefinitions%2Fname)}")
+		},
+		Changes: []string{
+			"breaking #/properties/app/links/0/href",
+		},
+	},
+	{
+		Patch: func(s *Schema) {
+			s.Definitions["app"].Links[1].TargetSchema = &Schema{Type: "object", Properties: map[string]*Schema{"id": {Type: "string"}}}
+		},
+		Changes: []string{
+			"breaking #/properties/app/links/1/targetSchema",
+		},
+	},
+	{
+		Patch: func(s *Schema) {
+			s.Definitions["app"].Required = []string{"id"}
+		},
+		Changes: []string{
+			"#/properties/app/properties/id",
+		},
+	},
+}
+
+func TestDiff(t *testing.T) {
+	for i, dt := range diffTests {
+		var from, to Schema
+		if err := json.Unmarshal([]byte(diffBase), &from); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal([]byte(diffBase), &to); err != nil {
+			t.Fatal(err)
+		}
+		dt.Patch(&to)
+		changes, err := Diff(&from, &to)
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		var found []string
+		for _, c := range changes {
+			if c.Breaking {
+				found = append(found, "breaking "+c.Pointer)
+			} else {
+				found = append(found, c.Pointer)
+			}
+		}
+		if !reflect.DeepEqual(found, dt.Changes) {
+			t.Errorf("%d: wants %v, got %v", i, dt.Changes, found)
+		}
+	}
+}
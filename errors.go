@@ -0,0 +1,58 @@
+package schematic
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SchemaError records a failure to process a schema element.
+type SchemaError struct {
+	// Pointer is the JSON pointer of the offending element, in URI
+	// fragment form (e.g. "#/definitions/app/properties/name").
+	Pointer string
+	// Err is the reason processing failed.
+	Err error
+	// anchored is true once the pointer leads to the element in its
+	// document, rather than along the references leading to it.
+	anchored bool
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Pointer, e.Err)
+}
+
+// Unwrap returns the reason processing failed.
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// schemaErrorf returns a SchemaError for the element at the given pointer.
+func schemaErrorf(pointer string, format string, a ...interface{}) error {
+	return &SchemaError{Pointer: pointer, Err: fmt.Errorf(format, a...)}
+}
+
+// within rebases a SchemaError whose pointer is relative to the schema it was
+// raised from onto the given pointer. Other errors are wrapped into a
+// SchemaError for that pointer.
+func within(pointer string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if se, ok := err.(*SchemaError); ok && se.anchored {
+		return se
+	}
+	if se, ok := err.(*SchemaError); ok && strings.HasPrefix(se.Pointer, fragment) {
+		return &SchemaError{
+			Pointer: pointer + strings.TrimPrefix(se.Pointer, fragment),
+			Err:     se.Err,
+		}
+	}
+	return &SchemaError{Pointer: pointer, Err: err}
+}
+
+func pointerTo(pointer string, tokens ...string) string {
+	for _, t := range tokens {
+		pointer += separator + encode(t)
+	}
+	return pointer
+}
@@ -0,0 +1,139 @@
+package schematic
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// FormatType is the Go type of the strings of a given format.
+type FormatType struct {
+	// Type is the Go type, qualified by its package name, e.g. "uuid.UUID".
+	Type string
+	// Import is the path of the package declaring the type, if any.
+	Import string
+}
+
+// ParseFormatType parses a Go type qualified by its import path, e.g.
+// "github.com/google/uuid.UUID".
+func ParseFormatType(s string) FormatType {
+	dot := strings.LastIndex(s, ".")
+	if dot < 0 || dot < strings.LastIndex(s, "/") {
+		return FormatType{Type: s}
+	}
+	p := s[:dot]
+	return FormatType{Type: importName(p) + s[dot:], Import: p}
+}
+
+// formatTypes maps string formats to Go types by default. Types without an
+// import path are either predeclared or declared by formats.tmpl.
+var formatTypes = map[string]FormatType{
+	"date-time": {Type: "time.Time", Import: "time"},
+	"date":      {Type: "Date"},
+	"duration":  {Type: "Duration"},
+	"uuid":      {Type: "UUID"},
+	"uri":       {Type: "URI"},
+	"email":     {Type: "Email"},
+	"ipv4":      {Type: "net.IP", Import: "net"},
+	"ipv6":      {Type: "net.IP", Import: "net"},
+	"byte":      {Type: "[]byte"},
+	"base64":    {Type: "[]byte"},
+}
+
+// stringDecls lists the types declared by formats.tmpl as strings.
+var stringDecls = map[string]bool{
+	"Email": true,
+	"URI":   true,
+	"UUID":  true,
+}
+
+// formatDecls lists the types declared by formats.tmpl, with the packages
+// their declarations import.
+var formatDecls = map[string][]string{
+	"Date":     {"net/url", "time"},
+	"Duration": {"fmt", "net/url", "regexp", "strconv", "strings", "time"},
+	"Email":    {"fmt", "net/mail"},
+	"URI":      {"fmt", "net/url"},
+	"UUID":     {"fmt", "regexp"},
+}
+
+// formatType returns the Go type of the strings of the given format,
+// recording the package or declaration it needs.
+func (g *generator) formatType(format string) string {
+	ft, ok := g.lookupFormat(format)
+	if !ok {
+		return "string"
+	}
+	if ft.Import != "" {
+		g.imports[ft.Import] = true
+	} else if imports, ok := formatDecls[ft.Type]; ok {
+		g.decls[ft.Type] = true
+		for _, p := range imports {
+			g.imports[p] = true
+		}
+		return g.runtimeType(ft.Type)
+	}
+	return ft.Type
+}
+
+// lookupFormat returns the Go type of the given format, set by the options
+// or by default.
+func (g *generator) lookupFormat(format string) (FormatType, bool) {
+	if ft, ok := g.opts.Formats[format]; ok {
+		return ft, true
+	}
+	ft, ok := formatTypes[format]
+	return ft, ok
+}
+
+// formatDecl is a format type to declare, of the given kind, e.g. "UUID",
+// under the given name.
+type formatDecl struct {
+	Kind string
+	Name string
+}
+
+// declaredFormats returns the format types to declare, sorted by kind.
+func (g *generator) declaredFormats() []formatDecl {
+	var kinds []string
+	for kind := range g.decls {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	decls := make([]formatDecl, len(kinds))
+	for i, kind := range kinds {
+		decls[i] = formatDecl{Kind: kind, Name: g.runtimeType(kind)}
+	}
+	return decls
+}
+
+// integerType returns the Go type of the integers of s, as hinted by its
+// format or picked from the range of its bounds. Unbounded integers are
+// ints.
+func integerType(s *Schema) string {
+	switch s.Format {
+	case "int32", "int64", "uint32", "uint64":
+		return s.Format
+	}
+	min, max := s.Minimum, s.Maximum
+	switch {
+	case min != nil && *min >= 0 && max != nil && *max > math.MaxInt64:
+		return "uint64"
+	case min != nil && *min >= math.MinInt32 && max != nil && *max <= math.MaxInt32:
+		return "int32"
+	case min != nil && *min < math.MinInt32, max != nil && *max > math.MaxInt32:
+		return "int64"
+	}
+	return "int"
+}
+
+// numberType returns the Go type of numbers, float64 unless set otherwise.
+func (g *generator) numberType() string {
+	if g.opts.Number.Type == "" {
+		return "float64"
+	}
+	if g.opts.Number.Import != "" {
+		g.imports[g.opts.Number.Import] = true
+	}
+	return g.opts.Number.Type
+}
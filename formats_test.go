@@ -0,0 +1,149 @@
+package schematic
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+var parseFormatTypeTests = []struct {
+	Value string
+	Type  FormatType
+}{
+	{
+		Value: "string",
+		Type:  FormatType{Type: "string"},
+	},
+	{
+		Value: "time.Duration",
+		Type:  FormatType{Type: "time.Duration", Import: "time"},
+	},
+	{
+		Value: "github.com/google/uuid.UUID",
+		Type:  FormatType{Type: "uuid.UUID", Import: "github.com/google/uuid"},
+	},
+	{
+		Value: "github.com/gofrs/uuid/v5.UUID",
+		Type:  FormatType{Type: "uuid.UUID", Import: "github.com/gofrs/uuid/v5"},
+	},
+}
+
+func TestParseFormatType(t *testing.T) {
+	for i, pt := range parseFormatTypeTests {
+		if ft := ParseFormatType(pt.Value); ft != pt.Type {
+			t.Errorf("%d: wants %v, got %v", i, pt.Type, ft)
+		}
+	}
+}
+
+func TestGenerateFormats(t *testing.T) {
+	s := &Schema{
+		Title: "Accounts",
+		Properties: map[string]*Schema{
+			"account": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"id":         {Type: "string", Format: "uuid"},
+					"born_on":    {Type: "string", Format: "date"},
+					"ip":         {Type: "string", Format: "ipv4"},
+					"reset_link": {Type: "string", Format: "uri"},
+				},
+				Links: []*Link{{Title: "Info", Method: "GET", HRef: NewHRef("/account")}},
+			},
+		},
+	}
+	src, err := s.GenerateWithOptions(Options{
+		Formats: map[string]FormatType{
+			"uuid": ParseFormatType("github.com/google/uuid.UUID"),
+			"uri":  {Type: "string"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Date", "NewDate"} {
+		if f.Scope.Lookup(name) == nil {
+			t.Errorf("expected %s to be declared", name)
+		}
+	}
+	for _, name := range []string{"UUID", "URI", "Email", "Duration"} {
+		if f.Scope.Lookup(name) != nil {
+			t.Errorf("expected %s not to be declared", name)
+		}
+	}
+	for _, s := range []string{`"github.com/google/uuid"`, `"net"`, "uuid.UUID", "net.IP", "ResetLink string"} {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("expected %s in the generated source", s)
+		}
+	}
+}
+
+func TestGenerateRuntimeTypes(t *testing.T) {
+	s := &Schema{
+		Title: "Accounts",
+		Properties: map[string]*Schema{
+			"email": {
+				Type:       "object",
+				Properties: map[string]*Schema{"address": {Type: "string", Format: "email"}},
+				Links:      []*Link{{Title: "Info", Method: "GET", HRef: NewHRef("/email")}},
+			},
+			"error": {
+				Type:       "object",
+				Properties: map[string]*Schema{"id": {Type: "string"}},
+				Links:      []*Link{{Title: "Info", Method: "GET", HRef: NewHRef("/error")}},
+			},
+		},
+	}
+	src, err := s.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Email", "Email2", "Error", "Error2"} {
+		if f.Scope.Lookup(name) == nil {
+			t.Errorf("expected %s to be declared", name)
+		}
+	}
+	for _, s := range []string{"Address Email2", "type Email2 string", "ID string", "func (e *Error2) Error() string"} {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("expected %s in the generated source", s)
+		}
+	}
+}
+
+func TestGenerateNumber(t *testing.T) {
+	s := &Schema{
+		Title: "Accounts",
+		Properties: map[string]*Schema{
+			"account": {
+				Type:       "object",
+				Properties: map[string]*Schema{"balance": {Type: "number"}},
+				Links:      []*Link{{Title: "Info", Method: "GET", HRef: NewHRef("/account")}},
+			},
+		},
+	}
+	src, err := s.GenerateWithOptions(Options{
+		Number:       ParseFormatType("github.com/shopspring/decimal.Decimal"),
+		PruneImports: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`"github.com/shopspring/decimal"`, "Balance decimal.Decimal"} {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("expected %s in the generated source", s)
+		}
+	}
+}
+
+func bound(v float64) *float64 {
+	return &v
+}
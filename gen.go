@@ -3,12 +3,15 @@ package schematic
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"go/format"
-	"os"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
+	"unicode"
 
 	bundle "github.com/interagent/schematic/templates"
 )
@@ -34,78 +37,374 @@ func (rs ResolvedSet) Has(o interface{}) bool {
 	return rs[o]
 }
 
+// Options configures the generated code.
+type Options struct {
+	// Package is the name of the generated package. It defaults to the
+	// first word of the schema title.
+	Package string
+	// Service is the name of the generated service type. It defaults to
+	// "Service".
+	Service string
+	// URL overrides the default base URL of the service, found in the
+	// schema "self" link.
+	URL string
+	// UserAgent is the prefix of the User-Agent header sent by the service.
+	// It defaults to the package name.
+	UserAgent string
+	// PruneImports only imports the packages used by the generated code.
+	PruneImports bool
+	// Fake generates an in-memory implementation of the service interface,
+	// whose methods are set with function fields.
+	Fake bool
+	// Server generates a handler interface with a method per link, and an
+	// http.Handler routing requests to it.
+	Server bool
+	// Formats maps string formats to Go types, overriding or extending the
+	// default mapping, e.g. "uuid" to the UUID type of a package.
+	Formats map[string]FormatType
+	// Number is the Go type of numbers, instead of float64, e.g.
+	// json.Number or a decimal type keeping their precision.
+	Number FormatType
+	// Templates is a directory whose .tmpl files override the bundled
+	// templates of the same name, e.g. struct.tmpl or funcs.tmpl.
+	Templates string
+}
+
 // Generate generates code according to the schema.
 func (s *Schema) Generate() ([]byte, error) {
+	return s.GenerateWithOptions(Options{})
+}
+
+// GenerateWithOptions generates code according to the schema and the given
+// options.
+func (s *Schema) GenerateWithOptions(opts Options) ([]byte, error) {
 	var buf bytes.Buffer
 
-	s = s.Resolve(nil, ResolvedSet{})
+	g, s, err := s.generator(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.service(&buf, s); err != nil {
+		return nil, err
+	}
+	for _, name := range s.resources() {
+		if err := g.resource(&buf, name, s.Properties[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := g.execute(&buf, "interface.tmpl", s.resourceContexts()); err != nil {
+		return nil, err
+	}
+	if g.opts.Fake {
+		if err := g.execute(&buf, "fake.tmpl", s.resourceContexts()); err != nil {
+			return nil, err
+		}
+	}
+	if g.opts.Server {
+		if err := g.execute(&buf, "server.tmpl", s.resourceContexts()); err != nil {
+			return nil, err
+		}
+	}
+	if err := g.execute(&buf, "formats.tmpl", g.declaredFormats()); err != nil {
+		return nil, err
+	}
 
-	name := strings.ToLower(strings.Split(s.Title, " ")[0])
-	templates.ExecuteTemplate(&buf, "package.tmpl", name)
+	// The imports are known once the code is generated.
+	var src bytes.Buffer
+	if err := g.header(&src, true); err != nil {
+		return nil, err
+	}
+	src.Write(buf.Bytes())
+	return g.format(src.Bytes(), g.opts.PruneImports)
+}
 
-	// TODO: Check if we need time.
-	templates.ExecuteTemplate(&buf, "imports.tmpl", []string{
-		"encoding/json", "fmt", "io", "reflect", "net/http", "runtime",
-		"time", "bytes", "context", "strings",
-		"github.com/google/go-querystring/query",
-	})
-	templates.ExecuteTemplate(&buf, "service.tmpl", struct {
-		Name    string
-		URL     string
-		Version string
-	}{
-		Name:    name,
-		URL:     s.URL(),
-		Version: s.Version,
-	})
+// GenerateFiles generates code according to the schema and the given
+// options, split into a "service.go" file holding the service and a file
+// per resource, named after it. Resources whose names would collide with
+// another file get a numeric suffix. Files are keyed by name.
+func (s *Schema) GenerateFiles(opts Options) (map[string][]byte, error) {
+	g, s, err := s.generator(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	bufs := make(map[string]*bytes.Buffer)
+	file := func(name string) *bytes.Buffer {
+		if buf, ok := bufs[name]; ok {
+			return buf
+		}
+		buf := new(bytes.Buffer)
+		bufs[name] = buf
+		return buf
+	}
+
+	if err := g.service(file("service.go"), s); err != nil {
+		return nil, err
+	}
+	names := fileNames(s.resources())
+	for _, name := range s.resources() {
+		if err := g.resource(file(names[name]), name, s.Properties[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := g.execute(file("service.go"), "interface.tmpl", s.resourceContexts()); err != nil {
+		return nil, err
+	}
+	if g.opts.Fake {
+		if err := g.execute(file("fake.go"), "fake.tmpl", s.resourceContexts()); err != nil {
+			return nil, err
+		}
+	}
+	if g.opts.Server {
+		if err := g.execute(file("server.go"), "server.tmpl", s.resourceContexts()); err != nil {
+			return nil, err
+		}
+	}
+	if err := g.execute(file("service.go"), "formats.tmpl", g.declaredFormats()); err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	for name, buf := range bufs {
+		// Only the service file documents the package.
+		var src bytes.Buffer
+		if err := g.header(&src, name == "service.go"); err != nil {
+			return nil, err
+		}
+		src.Write(buf.Bytes())
+		code, err := g.format(src.Bytes(), true)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		files[name] = code
+	}
+	return files, nil
+}
 
+// generator resolves the schema and returns a generator for it, with the
+// options defaults filled in.
+func (s *Schema) generator(opts Options) (*generator, *Schema, error) {
+	s, err := s.Resolve(nil, ResolvedSet{})
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.Package == "" {
+		opts.Package = strings.ToLower(strings.Split(s.Title, " ")[0])
+	}
+	if opts.Service == "" {
+		opts.Service = "Service"
+	}
+	if opts.URL == "" {
+		opts.URL = s.URL()
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = opts.Package
+	}
+	g := newGenerator()
+	g.opts = opts
+	g.locate(s, fragment)
+	g.reserve(s)
+	g.bind()
+	if opts.Templates != "" {
+		if err := g.override(opts.Templates); err != nil {
+			return nil, nil, err
+		}
+	}
+	return g, s, nil
+}
+
+// resources returns the sorted names of the schema properties declaring
+// resources.
+func (s *Schema) resources() []string {
+	var names []string
 	for _, name := range sortedKeys(s.Properties) {
 		schema := s.Properties[name]
 		// Skipping definitions because there is no links, nor properties.
 		if schema.Links == nil && schema.Properties == nil {
 			continue
 		}
+		names = append(names, name)
+	}
+	return names
+}
 
-		context := struct {
-			Name       string
-			Definition *Schema
-		}{
-			Name:       name,
-			Definition: schema,
-		}
+// resourceContexts returns the template contexts of the resources, as
+// used by the templates spanning all of them.
+func (s *Schema) resourceContexts() []resourceContext {
+	var contexts []resourceContext
+	for _, name := range s.resources() {
+		contexts = append(contexts, resourceContext{Name: name, Definition: s.Properties[name]})
+	}
+	return contexts
+}
+
+// resourceContext is the template context of a resource.
+type resourceContext struct {
+	Name       string
+	Definition *Schema
+}
 
-		if !context.Definition.AreTitleLinksUnique() {
-			return nil, fmt.Errorf("duplicate titles detected for %s", context.Name)
+// header writes the package clause, documented or not, and the imports.
+func (g *generator) header(w io.Writer, doc bool) error {
+	if doc {
+		if err := g.execute(w, "package.tmpl", g.opts.Package); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(w, "package %s\n", g.opts.Package)
+	}
+	imports := []string{
+		"encoding/json", "fmt", "io", "reflect", "net/http", "runtime",
+		"time", "bytes", "context", "strings", "strconv", "math/rand", "sync",
+		"regexp",
+	}
+	if g.opts.Server {
+		imports = append(imports, "errors", "net/url")
+	}
+	var used []string
+	for p := range g.imports {
+		if !contains(p, imports) {
+			used = append(used, p)
 		}
+	}
+	sort.Strings(used)
+	imports = append(imports, used...)
+	imports = append(imports, "github.com/google/go-querystring/query")
+	return g.execute(w, "imports.tmpl", imports)
+}
 
-		templates.ExecuteTemplate(&buf, "struct.tmpl", context)
-		templates.ExecuteTemplate(&buf, "funcs.tmpl", context)
+// service writes the service type and its helpers.
+func (g *generator) service(w io.Writer, s *Schema) error {
+	return g.execute(w, "service.tmpl", struct {
+		Name      string
+		URL       string
+		Version   string
+		UserAgent string
+	}{
+		Name:      g.opts.Package,
+		URL:       g.opts.URL,
+		Version:   s.Version,
+		UserAgent: g.opts.UserAgent,
+	})
+}
+
+// resource writes the types and methods of the named resource.
+func (g *generator) resource(w io.Writer, name string, schema *Schema) error {
+	context := resourceContext{
+		Name:       name,
+		Definition: schema,
 	}
 
-	// Remove blank lines added by text/template
-	bytes := newlines.ReplaceAll(buf.Bytes(), []byte(""))
+	if !context.Definition.AreTitleLinksUnique() {
+		return fmt.Errorf("duplicate titles detected for %s", context.Name)
+	}
+	if err := g.check(schema, name, pointerTo(fragment, "properties", name)); err != nil {
+		return err
+	}
+	types, err := g.nameTypes(schema, name)
+	if err != nil {
+		return within(pointerTo(fragment, "properties", name), err)
+	}
+
+	if err := g.execute(w, "struct.tmpl", context); err != nil {
+		return err
+	}
+	for _, t := range types {
+		if err := g.declaration(w, t); err != nil {
+			return err
+		}
+	}
+	return g.execute(w, "funcs.tmpl", context)
+}
+
+// format removes the blank lines added by text/template and formats the
+// source, pruning unused imports if asked to. Top-level declarations are
+// then separated by a blank line.
+func (g *generator) format(src []byte, prune bool) ([]byte, error) {
+	bytes := newlines.ReplaceAll(src, []byte(""))
+
+	if prune {
+		clean, err := pruneImports(bytes)
+		if err != nil {
+			return clean, err
+		}
+		return separate(clean), nil
+	}
 
 	// Format sources
 	clean, err := format.Source(bytes)
 	if err != nil {
-		return buf.Bytes(), err
+		return src, err
+	}
+	return separate(clean), nil
+}
+
+// declarationEnds matches the end of a formatted top-level declaration
+// directly followed by another one.
+var declarationEnds = regexp.MustCompile(`(?m)^([})])\n([^\n])`)
+
+// separate adds a blank line between the top-level declarations of a
+// formatted source, text/template blank lines being removed beforehand.
+func separate(src []byte) []byte {
+	return declarationEnds.ReplaceAll(src, []byte("$1\n\n$2"))
+}
+
+// fileName returns the name of the file holding the named resource.
+func fileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+	if name == "" {
+		name = "resource"
 	}
-	return clean, nil
+	return name + ".go"
+}
+
+// fileNames returns the names of the files holding the given resources,
+// adding a numeric suffix to the names already taken by the files of the
+// service or of the resources before.
+func fileNames(resources []string) map[string]string {
+	taken := map[string]bool{"service.go": true, "fake.go": true, "server.go": true}
+	names := make(map[string]string)
+	for _, r := range resources {
+		name := fileName(r)
+		base := strings.TrimSuffix(name, ".go")
+		for i := 2; taken[name]; i++ {
+			name = base + strconv.Itoa(i) + ".go"
+		}
+		taken[name] = true
+		names[r] = name
+	}
+	return names
 }
 
 // Resolve resolves reference inside the schema.
-func (s *Schema) Resolve(r *Schema, rs ResolvedSet) *Schema {
+func (s *Schema) Resolve(r *Schema, rs ResolvedSet) (*Schema, error) {
+	return s.resolve(r, rs, fragment)
+}
+
+func (s *Schema) resolve(r *Schema, rs ResolvedSet, pointer string) (*Schema, error) {
 	if r == nil {
 		r = s
 	}
 
+	followed := make(map[string]bool)
 	for {
 		if s.Ref != nil {
-			s = s.Ref.Resolve(r)
-		} else if len(s.OneOf) > 0 {
-			s = s.OneOf[0].Ref.Resolve(r)
-		} else if len(s.AnyOf) > 0 {
-			s = s.AnyOf[0].Ref.Resolve(r)
+			ref := *s.Ref
+			t, d, err := ref.resolve(r)
+			if err != nil {
+				return nil, &SchemaError{Pointer: pointerTo(pointer, "$ref"), Err: err}
+			}
+			target := ref.target(d, pointer)
+			if followed[target] {
+				return nil, schemaErrorf(pointerTo(pointer, "$ref"), "reference cycle through %s", target)
+			}
+			followed[target] = true
+			s, r, pointer = t, d, target
 		} else {
 			break
 		}
@@ -113,33 +412,132 @@ func (s *Schema) Resolve(r *Schema, rs ResolvedSet) *Schema {
 
 	if rs.Has(s) {
 		// Already resolved
-		return s
+		return s, nil
 	}
 	rs.Insert(s)
 
-	for n, d := range s.Definitions {
-		s.Definitions[n] = d.Resolve(r, rs)
+	var err error
+	for _, n := range sortedKeys(s.Definitions) {
+		if s.Definitions[n], err = s.Definitions[n].resolve(r, rs, pointerTo(pointer, "definitions", n)); err != nil {
+			return nil, err
+		}
 	}
-	for n, p := range s.Properties {
-		s.Properties[n] = p.Resolve(r, rs)
+	for _, n := range sortedKeys(s.Properties) {
+		if s.Properties[n], err = s.Properties[n].resolve(r, rs, pointerTo(pointer, "properties", n)); err != nil {
+			return nil, err
+		}
 	}
-	for n, p := range s.PatternProperties {
-		s.PatternProperties[n] = p.Resolve(r, rs)
+	for _, n := range sortedKeys(s.PatternProperties) {
+		if s.PatternProperties[n], err = s.PatternProperties[n].resolve(r, rs, pointerTo(pointer, "patternProperties", n)); err != nil {
+			return nil, err
+		}
 	}
 	if s.Items != nil {
-		s.Items = s.Items.Resolve(r, rs)
+		if s.Items, err = s.Items.resolve(r, rs, pointerTo(pointer, "items")); err != nil {
+			return nil, err
+		}
 	}
-	for _, l := range s.Links {
-		l.Resolve(r, rs)
+	for i, b := range s.OneOf {
+		if s.OneOf[i], err = b.resolve(r, rs, pointerTo(pointer, "oneOf", strconv.Itoa(i))); err != nil {
+			return nil, err
+		}
+	}
+	for i, b := range s.AnyOf {
+		if s.AnyOf[i], err = b.resolve(r, rs, pointerTo(pointer, "anyOf", strconv.Itoa(i))); err != nil {
+			return nil, err
+		}
+	}
+	for i, l := range s.Links {
+		if err := l.resolve(r, rs, pointerTo(pointer, "links", strconv.Itoa(i))); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.merge(r, rs, pointer); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// merge merges the properties, required properties and links of the allOf
+// subschemas into the schema, and narrows its type to the types they have in
+// common. Subschemas are merged only once: allOf is emptied afterwards.
+func (s *Schema) merge(r *Schema, rs ResolvedSet, pointer string) error {
+	for i, m := range s.AllOf {
+		mp := pointerTo(pointer, "allOf", strconv.Itoa(i))
+		m, err := m.resolve(r, rs, mp)
+		if err != nil {
+			return err
+		}
+		if s.Type == nil {
+			s.Type = m.Type
+		} else if m.Type != nil {
+			st, err := s.Types()
+			if err != nil {
+				return within(pointer, err)
+			}
+			mt, err := m.Types()
+			if err != nil {
+				return within(mp, err)
+			}
+			var types []interface{}
+			for _, t := range st {
+				if contains(t, mt) {
+					types = append(types, t)
+				}
+			}
+			switch len(types) {
+			case 0:
+				return schemaErrorf(mp, "conflicting types %v and %v", st, mt)
+			case 1:
+				s.Type = types[0]
+			default:
+				s.Type = types
+			}
+		}
+		if s.Description == "" {
+			s.Description = m.Description
+		}
+		for _, n := range sortedKeys(m.Properties) {
+			p := m.Properties[n]
+			if e, ok := s.Properties[n]; ok && e != p {
+				et, err := e.GoType()
+				if err != nil {
+					return within(pointerTo(pointer, "properties", n), err)
+				}
+				pt, err := p.GoType()
+				if err != nil {
+					return within(pointerTo(mp, "properties", n), err)
+				}
+				if et != pt {
+					return schemaErrorf(pointerTo(mp, "properties", n), "conflicting types %s and %s for property %s", et, pt, n)
+				}
+				continue
+			}
+			if s.Properties == nil {
+				s.Properties = make(map[string]*Schema)
+			}
+			s.Properties[n] = p
+		}
+		for _, n := range m.Required {
+			if !contains(n, s.Required) {
+				s.Required = append(s.Required, n)
+			}
+		}
+		s.Links = append(s.Links, m.Links...)
 	}
-	return s
+	s.AllOf = nil
+	return nil
 }
 
 // Types returns the array of types described by this schema.
 func (s *Schema) Types() (types []string, err error) {
 	if arr, ok := s.Type.([]interface{}); ok {
 		for _, v := range arr {
-			types = append(types, v.(string))
+			str, ok := v.(string)
+			if !ok {
+				return types, fmt.Errorf("unknown type %v", v)
+			}
+			types = append(types, str)
 		}
 	} else if str, ok := s.Type.(string); ok {
 		types = append(types, str)
@@ -150,8 +548,8 @@ func (s *Schema) Types() (types []string, err error) {
 }
 
 // GoType returns the Go type for the given schema as string.
-func (s *Schema) GoType() string {
-	return s.goType(true, true)
+func (s *Schema) GoType() (string, error) {
+	return newGenerator().goType(s, true, true)
 }
 
 // IsCustomType returns true if the schema declares a custom type.
@@ -159,79 +557,204 @@ func (s *Schema) IsCustomType() bool {
 	return len(s.Properties) > 0
 }
 
-func (s *Schema) goType(required bool, force bool) (goType string) {
+// IsUnion returns true if the schema is made of oneOf or anyOf branches.
+func (s *Schema) IsUnion() bool {
+	return len(s.Branches()) > 0
+}
+
+// Branches returns the oneOf or anyOf branches of the schema, but the null
+// ones, which only make its values nullable.
+func (s *Schema) Branches() []*Schema {
+	var branches []*Schema
+	for _, b := range s.allBranches() {
+		if !b.isNull() {
+			branches = append(branches, b)
+		}
+	}
+	return branches
+}
+
+// allBranches returns the oneOf or anyOf branches of the schema.
+func (s *Schema) allBranches() []*Schema {
+	if len(s.OneOf) > 0 {
+		return s.OneOf
+	}
+	return s.AnyOf
+}
+
+// nullable returns true if a branch of the schema accepts null values.
+func (s *Schema) nullable() bool {
+	return len(s.Branches()) < len(s.allBranches())
+}
+
+// isNull returns true if the schema only accepts null values.
+func (s *Schema) isNull() bool {
+	types, err := s.Types()
+	return err == nil && len(types) == 1 && types[0] == "null" && !s.IsUnion()
+}
+
+// branchPointer returns the pointer to the i-th branch returned by
+// Branches, relative to s.
+func (s *Schema) branchPointer(i int) string {
+	for j, b := range s.allBranches() {
+		if b.isNull() {
+			continue
+		}
+		if i == 0 {
+			return pointerTo(fragment, s.branchesKeyword(), strconv.Itoa(j))
+		}
+		i--
+	}
+	return fragment
+}
+
+func (s *Schema) branchesKeyword() string {
+	if len(s.OneOf) > 0 {
+		return "oneOf"
+	}
+	return "anyOf"
+}
+
+// IsEnum returns true if the schema declares a string enumeration.
+func (s *Schema) IsEnum() bool {
+	types, err := s.Types()
+	return err == nil && len(s.Enum) > 0 && contains("string", types)
+}
+
+// goType returns the Go type for the schema. Errors are SchemaErrors whose
+// pointers are relative to s.
+func (g *generator) goType(s *Schema, required bool, force bool) (goType string, err error) {
+	defer func() { err = g.anchor(s, err) }()
+	if s.IsUnion() {
+		if name, ok := g.types[s]; ok {
+			if !(required || force) || s.nullable() {
+				return "*" + name, nil
+			}
+			return name, nil
+		}
+		// Branches sharing the same type don't need a union.
+		if goType, err = g.commonType(s, required, force); goType != "" || err != nil {
+			if err == nil && s.nullable() && !nilable(goType) {
+				goType = "*" + goType
+			}
+			return goType, err
+		}
+		return "interface{}", nil
+	}
 	// Resolve JSON reference/pointer
 	types, err := s.Types()
 	if err != nil {
-		fail(s, err)
+		return "", &SchemaError{Pointer: fragment, Err: err}
 	}
 	for _, kind := range types {
 		switch kind {
 		case "boolean":
 			goType = "bool"
 		case "string":
-			switch s.Format {
-			case "date-time":
-				goType = "time.Time"
-			default:
-				goType = "string"
+			if name, ok := g.types[s]; ok {
+				goType = name
+				continue
 			}
+			goType = g.formatType(s.Format)
 		case "number":
-			goType = "float64"
+			goType = g.numberType()
 		case "integer":
-			goType = "int"
+			goType = integerType(s)
 		case "any":
 			goType = "interface{}"
 		case "array":
 			if s.Items != nil {
-				goType = "[]" + s.Items.goType(required, force)
+				it, err := g.goType(s.Items, required, force)
+				if err != nil {
+					return "", within(pointerTo(fragment, "items"), err)
+				}
+				goType = "[]" + it
 			} else {
 				goType = "[]interface{}"
 			}
 		case "object":
 			// Check if patternProperties exists.
 			if s.PatternProperties != nil {
-				for _, prop := range s.PatternProperties {
-					goType = fmt.Sprintf("map[string]%s", prop.GoType())
+				for _, name := range sortedKeys(s.PatternProperties) {
+					pt, err := g.goType(s.PatternProperties[name], true, true)
+					if err != nil {
+						return "", within(pointerTo(fragment, "patternProperties", name), err)
+					}
+					goType = fmt.Sprintf("map[string]%s", pt)
 					break // We don't support more than one pattern for now.
 				}
 				continue
 			}
-			buf := bytes.NewBufferString("struct {")
-			for _, name := range sortedKeys(s.Properties) {
-				prop := s.Properties[name]
-				req := contains(name, s.Required) || force
-				templates.ExecuteTemplate(buf, "field.tmpl", struct {
-					Definition *Schema
-					Name       string
-					Required   bool
-					Type       string
-				}{
-					Definition: prop,
-					Name:       name,
-					Required:   req,
-					Type:       prop.goType(req, force),
-				})
+			if name, ok := g.objects[g.named(s, force)]; ok {
+				goType = name
+				continue
+			}
+			if goType, err = g.structType(s, force); err != nil {
+				return "", err
 			}
-			buf.WriteString("}")
-			goType = buf.String()
 		case "null":
 			continue
 		default:
-			fail(s, fmt.Errorf("unknown type %s", kind))
+			return "", schemaErrorf(fragment, "unknown type %s", kind)
 		}
 	}
 	if goType == "" {
-		fail(s, fmt.Errorf("type not found : %s", types))
+		return "", schemaErrorf(fragment, "type not found : %s", types)
 	}
 	// Types allow null
 	if contains("null", types) || !(required || force) {
-		// Don't need a pointer for these types to be "nilable"
-		if goType != "interface{}" && !strings.HasPrefix(goType, "[]") && !strings.HasPrefix(goType, "map[") {
-			return "*" + goType
+		if !nilable(goType) {
+			return "*" + goType, nil
 		}
 	}
-	return goType
+	return goType, nil
+}
+
+// nilable returns true if values of the Go type can be nil without being
+// pointers.
+func nilable(goType string) bool {
+	return goType == "interface{}" || strings.HasPrefix(goType, "*") ||
+		strings.HasPrefix(goType, "[]") || strings.HasPrefix(goType, "map[")
+}
+
+// structType returns the struct type of an object, whose fields are all
+// required if forced. Requests leave read-only properties out.
+func (g *generator) structType(s *Schema, force bool) (string, error) {
+	buf := bytes.NewBufferString("struct {")
+	for _, name := range sortedKeys(s.Properties) {
+		prop := s.Properties[name]
+		// Read-only properties are set by the server.
+		if g.request && prop.ReadOnly {
+			continue
+		}
+		req := contains(name, s.Required) || force
+		pt, err := g.goType(prop, req, force)
+		if err != nil {
+			return "", within(pointerTo(fragment, "properties", name), err)
+		}
+		err = g.templates.ExecuteTemplate(buf, "field.tmpl", struct {
+			Definition *Schema
+			Name       string
+			Required   bool
+			Type       string
+		}{
+			Definition: prop,
+			Name:       name,
+			Required:   req,
+			Type:       pt,
+		})
+		if err != nil {
+			return "", within(pointerTo(fragment, "properties", name), err)
+		}
+	}
+	buf.WriteString("}")
+	return buf.String(), nil
+}
+
+// isStruct returns true for the objects whose Go type is a struct.
+func (s *Schema) isStruct() bool {
+	types, err := s.Types()
+	return err == nil && contains("object", types) && !s.IsUnion() && s.PatternProperties == nil && len(s.Properties) > 0
 }
 
 // Values returns function return values types.
@@ -287,27 +810,29 @@ func (s *Schema) ReturnsCustomType(l *Link) bool {
 }
 
 // ReturnedGoType returns Go type returned by the given link as a string.
-func (s *Schema) ReturnedGoType(name string, l *Link) string {
+func (s *Schema) ReturnedGoType(name string, l *Link) (string, error) {
+	return newGenerator().returnedGoType(s, name, l)
+}
+
+func (g *generator) returnedGoType(s *Schema, name string, l *Link) (string, error) {
 	if l.TargetSchema != nil {
 		if l.TargetSchema.Items == s {
-			return "[]" + initialCap(name)
+			return "[]" + initialCap(name), nil
 		}
-		return l.TargetSchema.goType(true, true)
+		return g.goType(l.TargetSchema, true, true)
 	}
-	return s.goType(true, true)
+	return g.goType(s, true, true)
 }
 
 // EmptyResult retursn true if the link result should be empty.
 func (s *Schema) EmptyResult(l *Link) bool {
-	var (
-		types []string
-		err   error
-	)
 	if l.TargetSchema != nil {
-		types, err = l.TargetSchema.Types()
-	} else {
-		types, err = s.Types()
+		s = l.TargetSchema
 	}
+	if s.IsUnion() {
+		return false
+	}
+	types, err := s.Types()
 	if err != nil {
 		return true
 	}
@@ -315,21 +840,32 @@ func (s *Schema) EmptyResult(l *Link) bool {
 }
 
 // Parameters returns function parameters names and types.
-func (l *Link) Parameters(name string) ([]string, map[string]string) {
+func (l *Link) Parameters(name string) ([]string, map[string]string, error) {
+	return newGenerator().parameters(l, name)
+}
+
+func (g *generator) parameters(l *Link, name string) ([]string, map[string]string, error) {
 	if l.HRef == nil {
 		// No HRef property
-		panic(fmt.Errorf("no href property declared for %s", l.Title))
+		return nil, nil, schemaErrorf(fragment, "no href property declared for %s", l.Title)
 	}
 	var order []string
 	params := make(map[string]string)
 	for _, name := range l.HRef.Order {
 		def := l.HRef.Schemas[name]
+		t, err := g.goType(def, true, true)
+		if err != nil {
+			return nil, nil, within(pointerTo(fragment, "href"), err)
+		}
 		order = append(order, name)
-		params[name] = def.GoType()
+		params[name] = t
 	}
 	if l.Schema != nil {
 		order = append(order, "o")
-		t, required := l.GoType()
+		t, required, err := g.linkGoType(l)
+		if err != nil {
+			return nil, nil, within(pointerTo(fragment, "schema"), err)
+		}
 		if l.AcceptsCustomType() {
 			params["o"] = paramType(name, l)
 		} else {
@@ -339,11 +875,17 @@ func (l *Link) Parameters(name string) ([]string, map[string]string) {
 			params["o"] = "*" + params["o"]
 		}
 	}
-	if l.Rel == "instances" && strings.ToUpper(l.Method) == "GET" {
+	if l.Paginated() {
 		order = append(order, "lr")
 		params["lr"] = "*ListRange"
 	}
-	return order, params
+	return order, params, nil
+}
+
+// Paginated returns true if the link lists instances in pages selected by
+// Range headers.
+func (l *Link) Paginated() bool {
+	return l.Rel == "instances" && strings.ToUpper(l.Method) == "GET"
 }
 
 // AcceptsCustomType returns true if the link schema is not a primitive type
@@ -355,33 +897,46 @@ func (l *Link) AcceptsCustomType() bool {
 }
 
 // Resolve resolve link schema and href.
-func (l *Link) Resolve(r *Schema, rs ResolvedSet) {
+func (l *Link) Resolve(r *Schema, rs ResolvedSet) error {
+	return l.resolve(r, rs, fragment)
+}
+
+func (l *Link) resolve(r *Schema, rs ResolvedSet, pointer string) error {
+	var err error
 	if l.Schema != nil {
-		l.Schema = l.Schema.Resolve(r, rs)
+		if l.Schema, err = l.Schema.resolve(r, rs, pointerTo(pointer, "schema")); err != nil {
+			return err
+		}
 	}
 	if l.TargetSchema != nil {
-		l.TargetSchema = l.TargetSchema.Resolve(r, rs)
+		if l.TargetSchema, err = l.TargetSchema.resolve(r, rs, pointerTo(pointer, "targetSchema")); err != nil {
+			return err
+		}
 	}
-	l.HRef.Resolve(r, rs)
+	if l.HRef == nil {
+		return schemaErrorf(pointer, "no href property declared for %s", l.Title)
+	}
+	if err := l.HRef.Resolve(r, rs); err != nil {
+		return &SchemaError{Pointer: pointerTo(pointer, "href"), Err: err}
+	}
+	return nil
 }
 
 // GoType returns Go type for the given schema as string and a bool specifying whether it is required
-func (l *Link) GoType() (string, bool) {
-	t := l.Schema.goType(true, false)
-	if t[0] == '*' {
-		return t[1:], false
-	}
-	return t, true
+func (l *Link) GoType() (string, bool, error) {
+	return newGenerator().linkGoType(l)
 }
 
-func fail(v interface{}, err error) {
-	el, _ := json.MarshalIndent(v, "    ", "  ")
-
-	fmt.Fprintf(
-		os.Stderr,
-		"Error processing schema element:\n    %s\n\nFailed with: %s\n",
-		el,
-		err,
-	)
-	os.Exit(1)
+func (g *generator) linkGoType(l *Link) (string, bool, error) {
+	request := g.request
+	g.request = true
+	t, err := g.goType(l.Schema, true, false)
+	g.request = request
+	if err != nil {
+		return "", false, err
+	}
+	if t[0] == '*' {
+		return t[1:], false, nil
+	}
+	return t, true, nil
 }
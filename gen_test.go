@@ -1,11 +1,15 @@
 package schematic
 
 import (
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -81,6 +85,41 @@ var generateTests = []struct {
 			},
 		},
 	},
+	{
+		ExpectedServiceFunctions: []string{"AccountList", "AccountListAll"},
+		Schema: &Schema{
+			Title: "Account Manager",
+			Properties: map[string]*Schema{
+				"account": {
+					Ref: NewReference("#/definitions/account"),
+				},
+			},
+			Definitions: map[string]*Schema{
+				"account": {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"name": {
+							Type: "string",
+						},
+					},
+					Links: []*Link{
+						{
+							Title:  "List",
+							Rel:    "instances",
+							HRef:   NewHRef("/accounts"),
+							Method: "GET",
+							TargetSchema: &Schema{
+								Type: "array",
+								Items: &Schema{
+									Ref: NewReference("#/definitions/account"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
 }
 
 func TestGenerate(t *testing.T) {
@@ -130,6 +169,731 @@ func TestGenerate(t *testing.T) {
 	}
 }
 
+func TestGenerateEnums(t *testing.T) {
+	schema := &Schema{
+		Title: "Account Manager",
+		Properties: map[string]*Schema{
+			"account": {
+				Ref: NewReference("#/definitions/account"),
+			},
+		},
+		Definitions: map[string]*Schema{
+			"account": {
+				Type: "object",
+				Definitions: map[string]*Schema{
+					"plan": {
+						Type: "string",
+						Enum: []string{"free", "hobby-dev"},
+					},
+				},
+				Properties: map[string]*Schema{
+					"plan": {
+						Ref: NewReference("#/definitions/account/definitions/plan"),
+					},
+				},
+			},
+		},
+	}
+	src, err := schema.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"AccountPlan", "AccountPlanFree", "AccountPlanHobbyDev"} {
+		if f.Scope.Lookup(name) == nil {
+			t.Errorf("expected %s to be declared", name)
+		}
+	}
+	if !strings.Contains(string(src), "Plan AccountPlan") {
+		t.Errorf("expected the plan field to use the AccountPlan type")
+	}
+}
+
+func TestGenerateUnions(t *testing.T) {
+	schema := &Schema{
+		Title: "Account Manager",
+		Properties: map[string]*Schema{
+			"account": {
+				Ref: NewReference("#/definitions/account"),
+			},
+		},
+		Definitions: map[string]*Schema{
+			"account": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"owner": {
+						Discriminator: "kind",
+						OneOf: []*Schema{
+							{
+								Type: "object",
+								Properties: map[string]*Schema{
+									"kind":  {Type: "string", Enum: []string{"user"}},
+									"email": {Type: "string"},
+								},
+							},
+							{
+								Title: "Team",
+								Type:  "object",
+								Properties: map[string]*Schema{
+									"kind": {Type: "string", Enum: []string{"team"}},
+									"name": {Type: "string"},
+								},
+							},
+						},
+					},
+					"plan": {
+						OneOf: []*Schema{
+							{Ref: NewReference("#/definitions/plan")},
+							{Type: "null"},
+						},
+					},
+				},
+			},
+			"plan": {
+				Type: "string",
+			},
+		},
+	}
+	src, err := schema.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := f.Scope.Lookup("AccountOwner")
+	if obj == nil {
+		t.Fatal("expected AccountOwner to be declared")
+	}
+	st, ok := obj.Decl.(*ast.TypeSpec).Type.(*ast.StructType)
+	if !ok {
+		t.Fatal("expected AccountOwner to be a struct")
+	}
+	var fields []string
+	for _, field := range st.Fields.List {
+		fields = append(fields, field.Names[0].Name)
+	}
+	if !reflect.DeepEqual(fields, []string{"User", "Team"}) {
+		t.Errorf("wants fields [User Team], got %v", fields)
+	}
+	if !strings.Contains(string(src), "Owner AccountOwner") {
+		t.Errorf("expected the owner field to use the AccountOwner type")
+	}
+	var plan string
+	for _, field := range f.Scope.Lookup("Account").Decl.(*ast.TypeSpec).Type.(*ast.StructType).Fields.List {
+		if field.Names[0].Name == "Plan" {
+			plan = string(src[field.Type.Pos()-1 : field.Type.End()-1])
+		}
+	}
+	if plan != "*string" {
+		t.Errorf("wants the nullable plan field to be a *string, got %q", plan)
+	}
+}
+
+func TestGenerateObjects(t *testing.T) {
+	region := func() *Schema {
+		return &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"id":   {Type: "string"},
+				"name": {Type: "string"},
+			},
+		}
+	}
+	schema := &Schema{
+		Title: "Platform",
+		Properties: map[string]*Schema{
+			"app": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"region": region(),
+					"owner":  region(),
+					"build-stack": {
+						Type:       "object",
+						Properties: map[string]*Schema{"name": {Type: "string"}},
+					},
+					"build": {
+						Type: "object",
+						Properties: map[string]*Schema{
+							"stack": {
+								Type:       "object",
+								Properties: map[string]*Schema{"name": {Type: "string"}},
+							},
+						},
+					},
+				},
+				Links: []*Link{
+					{
+						Title:  "Create",
+						Method: "POST",
+						HRef:   NewHRef("/apps"),
+						Schema: &Schema{
+							Type:       "object",
+							Properties: map[string]*Schema{"region": region()},
+						},
+					},
+				},
+			},
+			"space": {
+				Type:       "object",
+				Properties: map[string]*Schema{"region": region()},
+				Links:      []*Link{{Title: "Info", Method: "GET", HRef: NewHRef("/spaces")}},
+			},
+		},
+	}
+	src, err := schema.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := map[string]string{
+		"App.Build":            "AppBuild",
+		"App.Region":           "AppRegion",
+		"App.Owner":            "AppOwner",
+		"App.BuildStack":       "AppBuildStack",
+		"AppBuild.Stack":       "AppBuildStack",
+		"AppCreateOpts.Region": "AppCreateOptsRegion",
+		"Space.Region":         "SpaceRegion",
+	}
+	for field, want := range fields {
+		parts := strings.Split(field, ".")
+		obj := f.Scope.Lookup(parts[0])
+		if obj == nil {
+			t.Errorf("expected %s to be declared", parts[0])
+			continue
+		}
+		var got string
+		for _, fd := range obj.Decl.(*ast.TypeSpec).Type.(*ast.StructType).Fields.List {
+			if fd.Names[0].Name != parts[1] {
+				continue
+			}
+			switch ft := fd.Type.(type) {
+			case *ast.Ident:
+				got = ft.Name
+			case *ast.StarExpr:
+				got = ft.X.(*ast.Ident).Name
+			}
+		}
+		if got != want {
+			t.Errorf("%s: wants %s, got %s", field, want, got)
+		}
+	}
+	if f.Scope.Lookup("AppBuildStack2") != nil {
+		t.Errorf("expected AppBuildStack2 not to be declared")
+	}
+}
+
+func TestGenerateReadOnly(t *testing.T) {
+	id := &Schema{Type: "string", ReadOnly: true}
+	name := &Schema{Type: "string"}
+	schema := &Schema{
+		Title: "Platform",
+		Properties: map[string]*Schema{
+			"app": {
+				Type:       "object",
+				Properties: map[string]*Schema{"id": id, "name": name},
+				Links: []*Link{
+					{
+						Title:  "Create",
+						Method: "POST",
+						HRef:   NewHRef("/apps"),
+						Schema: &Schema{
+							Type:       "object",
+							Properties: map[string]*Schema{"id": id, "name": name},
+						},
+					},
+				},
+			},
+		},
+	}
+	src, err := schema.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := map[string][]string{
+		"App":           {"ID", "Name"},
+		"AppCreateOpts": {"Name"},
+	}
+	for typ, want := range fields {
+		var got []string
+		for _, fd := range f.Scope.Lookup(typ).Decl.(*ast.TypeSpec).Type.(*ast.StructType).Fields.List {
+			got = append(got, fd.Names[0].Name)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: wants %v, got %v", typ, want, got)
+		}
+	}
+}
+
+func TestGenerateFieldNames(t *testing.T) {
+	schema := &Schema{
+		Title: "Platform",
+		Properties: map[string]*Schema{
+			"certificate": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"ca_signed?": {Type: "boolean"},
+					"name":       {Type: "string"},
+				},
+				Links: []*Link{{Title: "Info", Method: "GET", HRef: NewHRef("/certificates")}},
+			},
+		},
+	}
+	src, err := schema.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := schema.typeCheck(token.NewFileSet(), newStubImporter(Options{}), Options{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"IsCaSigned bool", "func (e *Error) Error() string"} {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("expected %s in the generated source", s)
+		}
+	}
+}
+
+func TestGenerateValidate(t *testing.T) {
+	one := 1.0
+	schema := &Schema{
+		Title: "Platform",
+		Properties: map[string]*Schema{
+			"app": {
+				Type: "object",
+				Links: []*Link{
+					{
+						Title:  "Create",
+						Method: "POST",
+						HRef:   NewHRef("/apps"),
+						Schema: &Schema{
+							Type: "object",
+							Properties: map[string]*Schema{
+								"name":  {Type: "string", MinLength: 3, Pattern: "^[a-z]+$"},
+								"size":  {Type: "integer", Minimum: &one},
+								"stack": {Type: "string", Enum: []string{"cedar", "fir"}},
+								"owner": {Type: "string", Format: "email", MaxLength: 50},
+								"token": {Type: "string", Format: "ulid", Pattern: "^[0-9A-Z]{26}$"},
+								"born":  {Type: "string", Format: "date-time", MinLength: 20},
+								"id":    {Type: "string", ReadOnly: true, MinLength: 1},
+								"tags": {
+									Type:     "array",
+									MinItems: 1,
+									Items: &Schema{
+										Type:       "object",
+										Properties: map[string]*Schema{"key": {Type: "string", MaxLength: 2}},
+									},
+								},
+							},
+							Required: []string{"name", "tags"},
+						},
+					},
+				},
+			},
+		},
+	}
+	src, err := schema.GenerateWithOptions(Options{
+		Formats: map[string]FormatType{
+			"ulid": ParseFormatType("github.com/oklog/ulid/v2.ULID"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks := []string{
+		"func (o AppCreateOpts) Validate() error {",
+		`if utf8.RuneCountInString(o.Name) < 3 {`,
+		`v.add("name", "must be at least 3 characters long")`,
+		`if !matches("^[a-z]+$", o.Name) {`,
+		`if utf8.RuneCountInString(string((*o.Owner))) > 50 {`,
+		`if !matches("^[0-9A-Z]{26}$", fmt.Sprint((*o.Token))) {`,
+		`if (*o.Size) < 1 {`,
+		`v.add("stack", "must be one of \"cedar\", \"fir\"")`,
+		`v.add("tags", "is required")`,
+		`if o.Tags != nil && len(o.Tags) < 1 {`,
+		`v.add("tags", "must have at least 1 item")`,
+		`v.add(fmt.Sprintf("tags[%d].key", i1), "must be at most 2 characters long")`,
+	}
+	for i, c := range checks {
+		if !strings.Contains(string(src), c) {
+			t.Errorf("%d: wants %s, got none", i, c)
+		}
+	}
+	for _, field := range []string{"id", "born"} {
+		if strings.Contains(string(src), `v.add("`+field+`"`) {
+			t.Errorf("wants no check of %s, got one", field)
+		}
+	}
+}
+
+var outOfBoundTests = []struct {
+	Type      string
+	Bound     float64
+	Exclusive bool
+	Upper     bool
+	Expected  string
+}{
+	{"float64", 1.5, false, false, "x < 1.5"},
+	{"float64", 1.5, true, true, "x >= 1.5"},
+	{"int", 1.5, false, false, "x < 2"},
+	{"int", -1.5, true, false, "x < -1"},
+	{"int", 1, true, false, "x < 2"},
+	{"int", 1, true, true, "x > 0"},
+	{"int", 1 << 40, false, true, "int64(x) > 1099511627776"},
+	{"int64", 1<<53 + 2, false, true, "x > 9007199254740994"},
+	{"uint64", 1 << 63, false, false, "x < 9223372036854775808"},
+	{"uint64", 0, false, false, ""},
+	{"uint32", -1, true, true, "true"},
+	{"int32", 1 << 40, false, true, ""},
+}
+
+func TestOutOfBound(t *testing.T) {
+	for i, bt := range outOfBoundTests {
+		cond, _ := outOfBound("x", bt.Type, bt.Bound, bt.Exclusive, bt.Upper)
+		if cond != bt.Expected {
+			t.Errorf("%d: wants %q, got %q", i, bt.Expected, cond)
+		}
+	}
+}
+
+func TestGenerateWithOptions(t *testing.T) {
+	src, err := generateTests[0].Schema.GenerateWithOptions(Options{
+		Package:      "accounts",
+		Service:      "Client",
+		URL:          "https://example.com",
+		UserAgent:    "accounts-go",
+		PruneImports: true,
+		Fake:         true,
+		Server:       true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name.Name != "accounts" {
+		t.Errorf("wants package accounts, got %s", f.Name.Name)
+	}
+	for _, name := range []string{"Client", "NewClient", "ClientInterface", "FakeClient", "ClientHandler", "NewClientRouter"} {
+		if f.Scope.Lookup(name) == nil {
+			t.Errorf("expected %s to be declared", name)
+		}
+	}
+	if strings.Contains(string(src), "var _ = time.Second") {
+		t.Errorf("expected the time package placeholder to be removed")
+	}
+	for _, imp := range f.Imports {
+		p, _ := strconv.Unquote(imp.Path.Value)
+		if !strings.Contains(string(src), importName(p)+".") {
+			t.Errorf("expected the unused %s package not to be imported", p)
+		}
+	}
+	for _, s := range []string{`"https://example.com"`, `"accounts-go/"`} {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("expected %s in the generated source", s)
+		}
+	}
+}
+
+func TestPruneImports(t *testing.T) {
+	src, err := pruneImports([]byte(`package accounts
+
+import (
+	"fmt"
+	"time"
+)
+
+var _ = time.Second
+
+func Hello() string {
+	return fmt.Sprint("hello")
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, imp := range f.Imports {
+		if imp.Path.Value == `"time"` {
+			t.Errorf("expected the unused time package not to be imported")
+		}
+	}
+	if len(f.Imports) != 1 || len(f.Decls) != 2 {
+		t.Errorf("wants the fmt import and Hello, got %s", src)
+	}
+}
+
+var generateErrorTests = []struct {
+	Schema  *Schema
+	Pointer string
+}{
+	{
+		Schema: &Schema{
+			Title: "Account Manager",
+			Properties: map[string]*Schema{
+				"account": {
+					Ref: NewReference("#/definitions/account"),
+				},
+			},
+			Definitions: map[string]*Schema{
+				"account": {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"name": {
+							Type: "text",
+						},
+					},
+				},
+			},
+		},
+		Pointer: "#/definitions/account/properties/name",
+	},
+	{
+		Schema: &Schema{
+			Title: "Account Manager",
+			Properties: map[string]*Schema{
+				"account": {
+					Ref: NewReference("#/definitions/account"),
+				},
+			},
+			Definitions: map[string]*Schema{
+				"account": {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"name": {
+							Type: []interface{}{"string", 1.0},
+						},
+					},
+				},
+			},
+		},
+		Pointer: "#/definitions/account/properties/name",
+	},
+	{
+		Schema: &Schema{
+			Title: "Account Manager",
+			Properties: map[string]*Schema{
+				"account": {
+					Ref: NewReference("#/definitions/acount"),
+				},
+			},
+			Definitions: map[string]*Schema{
+				"account": {
+					Type: "object",
+				},
+			},
+		},
+		Pointer: "#/properties/account/$ref",
+	},
+	{
+		Schema: &Schema{
+			Title: "Account Manager",
+			Properties: map[string]*Schema{
+				"account": {
+					Ref: NewReference("#/definitions/account"),
+				},
+			},
+			Definitions: map[string]*Schema{
+				"account": {
+					Type: "object",
+					Links: []*Link{
+						{
+							Title:  "Info",
+							Rel:    "self",
+							Method: "GET",
+						},
+					},
+				},
+			},
+		},
+		Pointer: "#/definitions/account/links/0",
+	},
+	{
+		Schema: &Schema{
+			Title: "Account Manager",
+			Properties: map[string]*Schema{
+				"account": {
+					Ref: NewReference("#/definitions/account"),
+				},
+			},
+			Definitions: map[string]*Schema{
+				"account": {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"id": {
+							Type: "string",
+						},
+					},
+					AllOf: []*Schema{
+						{
+							Type: "object",
+							Properties: map[string]*Schema{
+								"id": {
+									Type: "integer",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Pointer: "#/definitions/account/allOf/0/properties/id",
+	},
+	{
+		Schema: &Schema{
+			Title: "Account Manager",
+			Properties: map[string]*Schema{
+				"account": {
+					Ref: NewReference("#/definitions/account"),
+				},
+			},
+			Definitions: map[string]*Schema{
+				"account": {
+					Type: "object",
+					AllOf: []*Schema{
+						{
+							Type: []interface{}{"object", "null"},
+						},
+						{
+							Type: "string",
+						},
+					},
+				},
+			},
+		},
+		Pointer: "#/definitions/account/allOf/1",
+	},
+}
+
+func TestGenerateFiles(t *testing.T) {
+	for i, gt := range generateTests {
+		files, err := gt.Schema.GenerateFiles(Options{})
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		if _, ok := files["service.go"]; !ok {
+			t.Errorf("%d: wants service.go among %d files", i, len(files))
+		}
+		declared := make(map[string]bool)
+		for name, src := range files {
+			f, err := parser.ParseFile(token.NewFileSet(), name, src, 0)
+			if err != nil {
+				t.Fatalf("%d: %s: %v", i, name, err)
+			}
+			for _, d := range f.Decls {
+				if fn, ok := d.(*ast.FuncDecl); ok {
+					declared[fn.Name.Name] = true
+				}
+			}
+		}
+		for _, fn := range gt.ExpectedServiceFunctions {
+			if !declared[fn] {
+				t.Errorf("%d: expected %s to be declared", i, fn)
+			}
+		}
+	}
+}
+
+func TestGenerateWithTemplates(t *testing.T) {
+	dir := t.TempDir()
+	overrides := map[string]string{
+		"struct.tmpl": "{{template \"doc.tmpl\" .Name}}\ntype {{initialCap .Name}} {{goType .Definition}}\n",
+		"doc.tmpl":    "// {{initialCap .}} is a custom resource.\n",
+	}
+	for name, text := range overrides {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src, err := generateTests[0].Schema.GenerateWithOptions(Options{Templates: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "// Account is a custom resource.") {
+		t.Errorf("expected the overriding struct template to be used")
+	}
+	if !strings.Contains(string(src), "func (s *Service) AccountCreate(") {
+		t.Errorf("expected the bundled funcs template to be used")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "funcs.tmpl"), []byte("{{unknown}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := generateTests[0].Schema.GenerateWithOptions(Options{Templates: dir}); err == nil {
+		t.Errorf("expected an error for an invalid template")
+	}
+
+	for _, missing := range []string{filepath.Join(dir, "missing"), filepath.Join(dir, "doc.tmpl")} {
+		if _, err := generateTests[0].Schema.GenerateWithOptions(Options{Templates: missing}); err == nil {
+			t.Errorf("expected an error for the templates directory %s", missing)
+		}
+	}
+}
+
+var fileNameTests = []struct {
+	Name     string
+	Expected string
+}{
+	{"app", "app.go"},
+	{"config-var", "configvar.go"},
+	{"add_on_test", "addontest.go"},
+	{"OAuth Client", "oauthclient.go"},
+	{"---", "resource.go"},
+}
+
+func TestFileName(t *testing.T) {
+	for i, ft := range fileNameTests {
+		if name := fileName(ft.Name); name != ft.Expected {
+			t.Errorf("%d: wants %v, got %v", i, ft.Expected, name)
+		}
+	}
+}
+
+func TestFileNames(t *testing.T) {
+	names := fileNames([]string{"app-setup", "app_setup", "fake", "service", "service2"})
+	expected := map[string]string{
+		"app-setup": "appsetup.go",
+		"app_setup": "appsetup2.go",
+		"fake":      "fake2.go",
+		"service":   "service2.go",
+		"service2":  "service22.go",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("wants %v, got %v", expected, names)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for i, tc := range generateErrorTests {
+		tc := tc
+		t.Run(fmt.Sprintf("generateErrorTests[%d]", i), func(t *testing.T) {
+			_, err := tc.Schema.Generate()
+			var se *SchemaError
+			if !errors.As(err, &se) {
+				t.Fatalf("wants a *SchemaError, got %v", err)
+			}
+			if se.Pointer != tc.Pointer {
+				t.Fatalf("wants pointer %s, got %s", tc.Pointer, se.Pointer)
+			}
+		})
+	}
+}
+
 var resolveTests = []struct {
 	Schema *Schema
 }{
@@ -196,16 +960,62 @@ var resolveTests = []struct {
 	},
 }
 
+func TestResolveAllOf(t *testing.T) {
+	schema := &Schema{
+		Definitions: map[string]*Schema{
+			"resource": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"id":         {Type: "string"},
+					"created_at": {Type: "string", Format: "date-time"},
+				},
+				Required: []string{"id"},
+				Links: []*Link{
+					{
+						Title: "Info",
+						HRef:  NewHRef("/resources"),
+					},
+				},
+			},
+		},
+		Properties: map[string]*Schema{
+			"account": {
+				AllOf: []*Schema{
+					{
+						Ref: NewReference("#/definitions/resource"),
+					},
+					{
+						Properties: map[string]*Schema{
+							"id":   {Type: "string"},
+							"name": {Type: "string"},
+						},
+						Required: []string{"id", "name"},
+					},
+				},
+			},
+		},
+	}
+	if _, err := schema.Resolve(nil, ResolvedSet{}); err != nil {
+		t.Fatal(err)
+	}
+	account := schema.Properties["account"]
+	if keys := sortedKeys(account.Properties); !reflect.DeepEqual(keys, []string{"created_at", "id", "name"}) {
+		t.Errorf("wants merged properties, got %v", keys)
+	}
+	if !reflect.DeepEqual(account.Required, []string{"id", "name"}) {
+		t.Errorf("wants merged required properties, got %v", account.Required)
+	}
+	if len(account.Links) != 1 || account.Type != "object" {
+		t.Errorf("wants merged links and type, got %v and %v", account.Links, account.Type)
+	}
+}
+
 func TestResolve(t *testing.T) {
 	for i, rt := range resolveTests {
 		t.Run(fmt.Sprintf("resolveTests[%d]", i), func(t *testing.T) {
-			defer func() {
-				if r := recover(); r != nil {
-					t.Fatal(r)
-				}
-			}()
-
-			rt.Schema.Resolve(nil, ResolvedSet{})
+			if _, err := rt.Schema.Resolve(nil, ResolvedSet{}); err != nil {
+				t.Fatal(err)
+			}
 		})
 	}
 }
@@ -251,12 +1061,64 @@ var typeTests = []struct {
 		},
 		Type: "time.Time",
 	},
+	{
+		Schema: &Schema{
+			Type:   "string",
+			Format: "uuid",
+		},
+		Type: "UUID",
+	},
+	{
+		Schema: &Schema{
+			Type:   []interface{}{"null", "string"},
+			Format: "ipv6",
+		},
+		Type: "*net.IP",
+	},
+	{
+		Schema: &Schema{
+			Type:   "string",
+			Format: "byte",
+		},
+		Type: "[]byte",
+	},
 	{
 		Schema: &Schema{
 			Type: []interface{}{"null", "string"},
 		},
 		Type: "*string",
 	},
+	{
+		Schema: &Schema{
+			Type:   "integer",
+			Format: "int64",
+		},
+		Type: "int64",
+	},
+	{
+		Schema: &Schema{
+			Type:    "integer",
+			Minimum: bound(-100),
+			Maximum: bound(100),
+		},
+		Type: "int32",
+	},
+	{
+		Schema: &Schema{
+			Type:    "integer",
+			Minimum: bound(0),
+			Maximum: bound(1 << 40),
+		},
+		Type: "int64",
+	},
+	{
+		Schema: &Schema{
+			Type:    "integer",
+			Minimum: bound(0),
+			Maximum: bound(1<<64 - 1),
+		},
+		Type: "uint64",
+	},
 	{
 		Schema: &Schema{
 			Type: "array",
@@ -330,11 +1192,41 @@ var typeTests = []struct {
 		},
 		Type: "interface{}",
 	},
+	{
+		Schema: &Schema{
+			AnyOf: []*Schema{
+				{
+					Type:   "string",
+					Format: "uuid",
+				},
+				{
+					Type: "string",
+				},
+			},
+		},
+		Type: "string",
+	},
+	{
+		Schema: &Schema{
+			OneOf: []*Schema{
+				{
+					Type: "string",
+				},
+				{
+					Type: "integer",
+				},
+			},
+		},
+		Type: "interface{}",
+	},
 }
 
 func TestSchemaType(t *testing.T) {
 	for i, tt := range typeTests {
-		kind := tt.Schema.GoType()
+		kind, err := tt.Schema.GoType()
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+		}
 		if !strings.Contains(kind, tt.Type) {
 			t.Errorf("%d: wants %v, got %v", i, tt.Type, kind)
 		}
@@ -376,7 +1268,10 @@ var linkTests = []struct {
 
 func TestLinkType(t *testing.T) {
 	for i, lt := range linkTests {
-		kind, _ := lt.Link.GoType()
+		kind, _, err := lt.Link.GoType()
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+		}
 		if !strings.Contains(kind, lt.Type) {
 			t.Errorf("%d: wants %v, got %v", i, lt.Type, kind)
 		}
@@ -523,8 +1418,14 @@ var paramsTests = []struct {
 
 func TestParameters(t *testing.T) {
 	for i, pt := range paramsTests {
-		pt.Link.Resolve(pt.Schema, ResolvedSet{})
-		order, params := pt.Link.Parameters("link")
+		if err := pt.Link.Resolve(pt.Schema, ResolvedSet{}); err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		order, params, err := pt.Link.Parameters("link")
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+		}
 		if !reflect.DeepEqual(order, pt.Order) {
 			t.Errorf("%d: wants %v, got %v", i, pt.Order, order)
 		}
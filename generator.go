@@ -0,0 +1,716 @@
+package schematic
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var (
+	identifier   = regexp.MustCompile(`^[A-Za-z_][\w.]*$`)
+	majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+)
+
+// generator holds the state of a single code generation run.
+type generator struct {
+	opts      Options
+	templates *template.Template
+	// types maps schemas to the name of the Go type declared for them.
+	types map[*Schema]string
+	// objects maps objects to the name of the struct type declared for
+	// them, and shapes maps the names and shapes of those objects to the
+	// same names.
+	objects map[namedType]string
+	shapes  map[string]string
+	// names maps declared type names back to their schema.
+	names map[string]*Schema
+	// request is true while computing the types of requests.
+	request bool
+	// imports and decls record the packages and the format types used by
+	// the generated code.
+	imports map[string]bool
+	decls   map[string]bool
+	// locations maps resolved schemas to where they are found in the
+	// document, their definitions rather than the references to them.
+	locations map[*Schema]string
+	// runtime maps the types declared by the templates, such as Error, to
+	// the names they are given, which resources take precedence over.
+	runtime map[string]string
+}
+
+func newGenerator() *generator {
+	return &generator{
+		opts:      Options{Service: "Service"},
+		templates: templates,
+		types:     make(map[*Schema]string),
+		objects:   make(map[namedType]string),
+		shapes:    make(map[string]string),
+		names:     make(map[string]*Schema),
+		imports:   make(map[string]bool),
+		decls:     make(map[string]bool),
+		runtime:   make(map[string]string),
+		locations: make(map[*Schema]string),
+	}
+}
+
+// locate records where the resolved schema s, found at pointer, and its
+// subschemas are, in the order they were resolved: definitions come first.
+func (g *generator) locate(s *Schema, pointer string) {
+	if s == nil {
+		return
+	}
+	if _, ok := g.locations[s]; ok {
+		return
+	}
+	g.locations[s] = pointer
+	for _, n := range sortedKeys(s.Definitions) {
+		g.locate(s.Definitions[n], pointerTo(pointer, "definitions", n))
+	}
+	for _, n := range sortedKeys(s.Properties) {
+		g.locate(s.Properties[n], pointerTo(pointer, "properties", n))
+	}
+	for _, n := range sortedKeys(s.PatternProperties) {
+		g.locate(s.PatternProperties[n], pointerTo(pointer, "patternProperties", n))
+	}
+	g.locate(s.Items, pointerTo(pointer, "items"))
+	for i, b := range s.OneOf {
+		g.locate(b, pointerTo(pointer, "oneOf", strconv.Itoa(i)))
+	}
+	for i, b := range s.AnyOf {
+		g.locate(b, pointerTo(pointer, "anyOf", strconv.Itoa(i)))
+	}
+	for i, l := range s.Links {
+		lp := pointerTo(pointer, "links", strconv.Itoa(i))
+		g.locate(l.Schema, pointerTo(lp, "schema"))
+		g.locate(l.TargetSchema, pointerTo(lp, "targetSchema"))
+	}
+}
+
+// anchor rebases a SchemaError whose pointer is relative to s onto where s
+// is found, rather than along the references leading to it.
+func (g *generator) anchor(s *Schema, err error) error {
+	se, ok := err.(*SchemaError)
+	pointer, found := g.locations[s]
+	if !ok || se.anchored || !found || !strings.HasPrefix(se.Pointer, fragment) {
+		return err
+	}
+	return &SchemaError{
+		Pointer:  pointer + strings.TrimPrefix(se.Pointer, fragment),
+		Err:      se.Err,
+		anchored: true,
+	}
+}
+
+// bind gives the generator its own copy of the templates, with the helpers
+// depending on declared types bound to the generator.
+func (g *generator) bind() {
+	g.templates = template.Must(g.templates.Clone()).Funcs(template.FuncMap{
+		"goType": func(s *Schema) (string, error) {
+			return g.goType(s, true, true)
+		},
+		"linkGoType": func(l *Link) (string, error) {
+			t, _, err := g.linkGoType(l)
+			return t, err
+		},
+		"returnedGoType": g.returnedGoType,
+		"listItemType":   g.listItemType,
+		"params":         g.params,
+		"paramList":      g.paramList,
+		"validation":     g.validation,
+		"runtimeType":    g.runtimeType,
+		"service": func() string {
+			return g.opts.Service
+		},
+	})
+}
+
+// override replaces the bundled templates by the .tmpl files of the given
+// directory sharing their name. Other templates found there are made
+// available to the overriding ones. The directory must exist.
+func (g *generator) override(dir string) error {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		text, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		if _, err := g.templates.New(filepath.Base(f)).Parse(string(text)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execute applies the named template, unwrapping schema errors returned by
+// helpers so callers can inspect them.
+func (g *generator) execute(w io.Writer, name string, data interface{}) error {
+	err := g.templates.ExecuteTemplate(w, name, data)
+	var se *SchemaError
+	if errors.As(err, &se) {
+		return se
+	}
+	return err
+}
+
+// check reports the first error raised while computing the Go types used
+// by the resource at the given pointer, before any code is generated.
+func (g *generator) check(s *Schema, name, pointer string) error {
+	if _, err := g.goType(s, true, true); err != nil {
+		return within(pointer, err)
+	}
+	for i, l := range s.Links {
+		lp := pointerTo(pointer, "links", strconv.Itoa(i))
+		if _, _, err := g.parameters(l, name); err != nil {
+			return within(lp, err)
+		}
+		if l.Schema != nil {
+			if _, _, err := g.linkGoType(l); err != nil {
+				return within(pointerTo(lp, "schema"), err)
+			}
+		}
+		if _, err := g.returnedGoType(s, name, l); err != nil {
+			return within(pointerTo(lp, "targetSchema"), err)
+		}
+	}
+	return nil
+}
+
+func (g *generator) params(name string, l *Link) (string, error) {
+	var p []string
+	list, err := g.paramList(name, l)
+	if err != nil {
+		return "", err
+	}
+	for _, param := range list {
+		p = append(p, fmt.Sprintf("%s %s", param.Name, param.Type))
+	}
+	return strings.Join(p, ", "), nil
+}
+
+// param is a parameter of the method generated for a link.
+type param struct {
+	Name string
+	Type string
+}
+
+// paramList returns the parameters of the method generated for a link, the
+// href variables coming first.
+func (g *generator) paramList(name string, l *Link) ([]param, error) {
+	var list []param
+	order, params, err := g.parameters(l, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range order {
+		list = append(list, param{Name: initialLow(n), Type: params[n]})
+	}
+	return list, nil
+}
+
+// listItemType returns the type of the items listed by a link, or an empty
+// string if the link doesn't return a list.
+func (g *generator) listItemType(s *Schema, name string, l *Link) (string, error) {
+	t, err := g.returnedGoType(s, name, l)
+	if err != nil || !strings.HasPrefix(t, "[]") {
+		return "", err
+	}
+	return strings.TrimPrefix(t, "[]"), nil
+}
+
+// namedType is a schema needing a type declaration. The Go types of
+// objects depend on whether their fields are forced, as in responses, or
+// may be left out, and on whether they are part of a request, which leaves
+// out read-only properties.
+type namedType struct {
+	Schema  *Schema
+	Force   bool
+	Request bool
+}
+
+// named returns the named type of s in the current context.
+func (g *generator) named(s *Schema, force bool) namedType {
+	return namedType{Schema: s, Force: force, Request: g.request}
+}
+
+// nameTypes names the enums, unions and nested objects used by a resource,
+// its links and their href variables after the property path leading to
+// them. It returns the types that were not named before and need to be
+// declared.
+func (g *generator) nameTypes(s *Schema, name string) (types []namedType, err error) {
+	rs := ResolvedSet{}
+	rs.Insert(g.named(s, true))
+	if types, err = g.nameChildren(types, s, name, true, rs); err != nil {
+		return nil, err
+	}
+	for _, l := range s.Links {
+		if l.HRef != nil {
+			for _, n := range l.HRef.Order {
+				if types, err = g.nameType(types, l.HRef.Schemas[n], n, true, rs); err != nil {
+					return nil, err
+				}
+			}
+		}
+		// The link schemas themselves are declared by funcs.tmpl.
+		if l.Schema != nil {
+			g.request = true
+			if t := g.named(l.Schema, false); !rs.Has(t) {
+				rs.Insert(t)
+				types, err = g.nameChildren(types, l.Schema, paramType(name, l), false, rs)
+			}
+			g.request = false
+			if err != nil {
+				return nil, err
+			}
+		}
+		if t := g.named(l.TargetSchema, true); defineCustomType(s, l) && !rs.Has(t) {
+			rs.Insert(t)
+			if types, err = g.nameChildren(types, l.TargetSchema, returnType(name, s, l), true, rs); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return types, nil
+}
+
+// nameType names s after name.
+func (g *generator) nameType(types []namedType, s *Schema, name string, force bool, rs ResolvedSet) ([]namedType, error) {
+	t := g.named(s, force)
+	if s == nil || rs.Has(t) {
+		return types, nil
+	}
+	rs.Insert(t)
+	types, err := g.nameChildren(types, s, name, force, rs)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case s.IsEnum():
+		if g.declare(s, initialCap(name)) {
+			types = append(types, t)
+		}
+	case s.IsUnion():
+		if t, err := g.commonType(s, true, true); err != nil || t != "" {
+			return types, err
+		}
+		if g.declare(s, initialCap(name)) {
+			types = append(types, t)
+		}
+	case s.isStruct():
+		declared, err := g.declareObject(t, initialCap(name))
+		if err != nil {
+			return nil, err
+		}
+		if declared {
+			types = append(types, t)
+		}
+	}
+	return types, nil
+}
+
+func (g *generator) nameChildren(types []namedType, s *Schema, name string, force bool, rs ResolvedSet) ([]namedType, error) {
+	var err error
+	for _, n := range sortedKeys(s.Properties) {
+		if types, err = g.nameType(types, s.Properties[n], name+"-"+n, force, rs); err != nil {
+			return nil, err
+		}
+	}
+	// The fields of unions hold optional values.
+	for i, b := range s.Branches() {
+		label := s.branchLabel(i)
+		if types, err = g.nameType(types, b, name+"-"+label, false, rs); err != nil {
+			return nil, err
+		}
+	}
+	return g.nameType(types, s.Items, name, force, rs)
+}
+
+// commonType returns the Go type shared by all the branches of a union, or
+// an empty string if they don't share one. Strings of different formats,
+// such as an id and a name, share the string type.
+func (g *generator) commonType(s *Schema, required bool, force bool) (string, error) {
+	var common string
+	mixed, allStrings := false, true
+	for i, b := range s.Branches() {
+		t, err := g.goType(b, required, force)
+		if err != nil {
+			return "", within(s.branchPointer(i), err)
+		}
+		if i > 0 && t != common {
+			mixed = true
+		}
+		common = t
+		allStrings = allStrings && isString(b)
+	}
+	switch {
+	case !mixed:
+		return common, nil
+	case allStrings:
+		return g.goType(&Schema{Type: "string"}, required, force)
+	}
+	return "", nil
+}
+
+// isString returns true for the schemas of strings, whatever their format,
+// which are not enumerations.
+func isString(s *Schema) bool {
+	types, err := s.Types()
+	return err == nil && len(s.Enum) == 0 && !s.IsUnion() && len(types) == 1 && types[0] == "string"
+}
+
+// unionBranch describes a field of a union type.
+type unionBranch struct {
+	Field string
+	Type  string
+	// Values are the discriminator values selecting the branch.
+	Values []string
+}
+
+// Pointer returns true if the field holds a pointer to the branch value.
+func (b unionBranch) Pointer() bool {
+	return strings.HasPrefix(b.Type, "*")
+}
+
+// branches returns the fields of the union type declared for s.
+func (g *generator) branches(s *Schema) ([]unionBranch, error) {
+	var branches []unionBranch
+	fields := make(map[string]bool)
+	for i, b := range s.Branches() {
+		t, err := g.goType(b, false, false)
+		if err != nil {
+			return nil, within(s.branchPointer(i), err)
+		}
+		field := g.types[b]
+		if field == "" {
+			field = initialCap(s.branchLabel(i))
+		}
+		if _, err := strconv.Atoi(field); err == nil && identifier.MatchString(strings.TrimPrefix(t, "*")) {
+			parts := strings.Split(strings.TrimPrefix(t, "*"), ".")
+			field = initialCap(parts[len(parts)-1])
+		}
+		if _, err := strconv.Atoi(field); err == nil || fields[field] {
+			field = fmt.Sprintf("Option%d", i+1)
+		}
+		if contains(field, []string{"String", "MarshalJSON", "UnmarshalJSON"}) {
+			// Reserved by the methods of the union.
+			field += "Value"
+		}
+		fields[field] = true
+
+		branches = append(branches, unionBranch{
+			Field:  field,
+			Type:   t,
+			Values: s.discriminatorValues(i),
+		})
+	}
+	return branches, nil
+}
+
+// branchLabel returns a name for the i-th branch of s: its title, its first
+// discriminator value or its position.
+func (s *Schema) branchLabel(i int) string {
+	b := s.Branches()[i]
+	if b.Title != "" {
+		return b.Title
+	}
+	if v := s.discriminatorValues(i); len(v) > 0 && v[0] != "" {
+		return v[0]
+	}
+	return strconv.Itoa(i + 1)
+}
+
+// discriminatorValues returns the discriminator values selecting the i-th
+// branch of s.
+func (s *Schema) discriminatorValues(i int) []string {
+	if s.Discriminator == "" {
+		return nil
+	}
+	if d, ok := s.Branches()[i].Properties[s.Discriminator]; ok {
+		return d.Enum
+	}
+	return nil
+}
+
+// declaration executes the template declaring the named type.
+func (g *generator) declaration(w io.Writer, t namedType) error {
+	s := t.Schema
+	g.request = t.Request
+	defer func() { g.request = false }()
+	if s.IsEnum() {
+		return g.execute(w, "enum.tmpl", struct {
+			Name       string
+			Definition *Schema
+		}{
+			Name:       g.types[s],
+			Definition: s,
+		})
+	}
+	if !s.IsUnion() {
+		body, err := g.structType(s, t.Force)
+		if err != nil {
+			return err
+		}
+		return g.execute(w, "object.tmpl", struct {
+			Name       string
+			Definition *Schema
+			Type       string
+		}{
+			Name:       g.objects[t],
+			Definition: s,
+			Type:       body,
+		})
+	}
+	branches, err := g.branches(s)
+	if err != nil {
+		return err
+	}
+	return g.execute(w, "union.tmpl", struct {
+		Name       string
+		Definition *Schema
+		Branches   []unionBranch
+	}{
+		Name:       g.types[s],
+		Definition: s,
+		Branches:   branches,
+	})
+}
+
+// declare names the type declared for s, returning false if no new type
+// needs to be declared. Identical enums share the same type.
+func (g *generator) declare(s *Schema, name string) bool {
+	if _, ok := g.types[s]; ok {
+		return false
+	}
+	base := name
+	for i := 2; ; i++ {
+		d, ok := g.names[name]
+		if !ok {
+			break
+		}
+		if d.IsEnum() && equalStrings(d.Enum, s.Enum) {
+			g.types[s] = name
+			return false
+		}
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	g.types[s] = name
+	g.names[name] = s
+	return true
+}
+
+// reserve keeps the names of the types declared for the resources and
+// their links from being given to other types.
+func (g *generator) reserve(s *Schema) {
+	for _, name := range s.resources() {
+		r := s.Properties[name]
+		g.names[initialCap(name)] = r
+		for _, l := range r.Links {
+			if l.AcceptsCustomType() {
+				g.names[paramType(name, l)] = l.Schema
+			}
+			if defineCustomType(r, l) {
+				g.names[returnType(name, r, l)] = l.TargetSchema
+			}
+		}
+	}
+	for _, base := range runtimeTypes() {
+		name := base
+		for i := 2; g.names[name] != nil; i++ {
+			name = fmt.Sprintf("%s%d", base, i)
+		}
+		g.names[name] = &Schema{}
+		g.runtime[base] = name
+	}
+}
+
+// runtimeTypes returns the sorted names of the types the templates may
+// declare along with those of the schema.
+func runtimeTypes() []string {
+	names := []string{"Error"}
+	for name := range formatDecls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runtimeType returns the name given to a type declared by the templates.
+func (g *generator) runtimeType(name string) string {
+	if n, ok := g.runtime[name]; ok {
+		return n
+	}
+	return name
+}
+
+// declareObject names the struct type declared for an object, returning
+// false if no new type needs to be declared. Objects of identical shapes
+// named alike, such as the "build-stack" property of an app and the "stack"
+// of its build, share the same type. Others named alike get a numeric
+// suffix, in the order of their properties.
+func (g *generator) declareObject(t namedType, name string) (bool, error) {
+	if _, ok := g.objects[t]; ok {
+		return false, nil
+	}
+	shape, err := g.shape(t.Schema, t.Force)
+	if err != nil {
+		return false, err
+	}
+	shape = name + " " + shape
+	if n, ok := g.shapes[shape]; ok {
+		g.objects[t] = n
+		return false, nil
+	}
+	base := name
+	for i := 2; g.names[name] != nil; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	g.objects[t] = name
+	g.names[name] = t.Schema
+	g.shapes[shape] = name
+	return true, nil
+}
+
+// shape describes the fields of an object, leaving their documentation out.
+func (g *generator) shape(s *Schema, force bool) (string, error) {
+	var b strings.Builder
+	for _, name := range sortedKeys(s.Properties) {
+		if g.request && s.Properties[name].ReadOnly {
+			continue
+		}
+		req := contains(name, s.Required) || force
+		t, err := g.goType(s.Properties[name], req, force)
+		if err != nil {
+			return "", within(pointerTo(fragment, "properties", name), err)
+		}
+		fmt.Fprintf(&b, "%s %s %t;", name, t, req)
+	}
+	return b.String(), nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// pruneImports removes the imports unused by the generated source, along
+// with the blank declaration keeping the time package in use, and formats
+// it.
+func pruneImports(src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return src, err
+	}
+
+	var decls []ast.Decl
+	for _, d := range f.Decls {
+		if isTimeKeeper(d) {
+			continue
+		}
+		decls = append(decls, d)
+	}
+	f.Decls = decls
+
+	used := make(map[string]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Obj == nil {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+
+	var imports []*ast.ImportSpec
+	decls = nil
+	for _, d := range f.Decls {
+		gd, ok := d.(*ast.GenDecl)
+		if !ok || gd.Tok != token.IMPORT {
+			decls = append(decls, d)
+			continue
+		}
+		var specs []ast.Spec
+		for _, spec := range gd.Specs {
+			is := spec.(*ast.ImportSpec)
+			p, _ := strconv.Unquote(is.Path.Value)
+			name := importName(p)
+			if is.Name != nil {
+				name = is.Name.Name
+			}
+			if used[name] {
+				specs = append(specs, is)
+				imports = append(imports, is)
+			}
+		}
+		if len(specs) > 0 {
+			gd.Specs = specs
+			decls = append(decls, gd)
+		}
+	}
+	f.Decls, f.Imports = decls, imports
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, f); err != nil {
+		return src, err
+	}
+	// Reformat to clean up emptied declarations.
+	return format.Source(buf.Bytes())
+}
+
+// importName returns the name a package is imported with by default,
+// skipping the major version suffix of its path, e.g. "uuid" for
+// "github.com/gofrs/uuid/v5".
+func importName(p string) string {
+	name := path.Base(p)
+	if majorVersion.MatchString(name) && path.Dir(p) != "." {
+		name = path.Base(path.Dir(p))
+	}
+	return name
+}
+
+// isTimeKeeper returns true for the "var _ = time.Second" declaration.
+func isTimeKeeper(d ast.Decl) bool {
+	gd, ok := d.(*ast.GenDecl)
+	if !ok || gd.Tok != token.VAR || len(gd.Specs) != 1 {
+		return false
+	}
+	vs := gd.Specs[0].(*ast.ValueSpec)
+	if len(vs.Names) != 1 || vs.Names[0].Name != "_" || len(vs.Values) != 1 {
+		return false
+	}
+	sel, ok := vs.Values[0].(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	x, ok := sel.X.(*ast.Ident)
+	return ok && x.Name == "time" && sel.Sel.Name == "Second"
+}
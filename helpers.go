@@ -19,14 +19,25 @@ var helpers = template.FuncMap{
 	"fieldName":        fieldName,
 	"fieldTag":         fieldTag,
 	"params":           params,
+	"paramNames":       paramNames,
+	"paramList":        paramList,
+	"route":            route,
 	"requestParams":    requestParams,
 	"args":             args,
 	"values":           values,
 	"goType":           goType,
 	"linkGoType":       linkGoType,
 	"returnType":       returnType,
+	"returnedGoType":   returnedGoType,
+	"listItemType":     listItemType,
 	"defineCustomType": defineCustomType,
 	"paramType":        paramType,
+	"validation":       validation,
+	"runtimeType":      runtimeType,
+	"enumConstant":     enumConstant,
+	"enumConstants":    enumConstants,
+	"enumValues":       enumValues,
+	"service":          service,
 }
 
 var (
@@ -35,13 +46,33 @@ var (
 	camelcase = regexp.MustCompile(`(?m)[-.$/:_{}\s]+`)
 )
 
-func goType(p *Schema) string {
+func service() string {
+	return "Service"
+}
+
+func goType(p *Schema) (string, error) {
 	return p.GoType()
 }
 
-func linkGoType(l *Link) string {
-	t, _ := l.GoType()
-	return t
+func linkGoType(l *Link) (string, error) {
+	t, _, err := l.GoType()
+	return t, err
+}
+
+func runtimeType(name string) string {
+	return name
+}
+
+func validation(name string, l *Link) (string, error) {
+	return newGenerator().validation(name, l)
+}
+
+func returnedGoType(s *Schema, name string, l *Link) (string, error) {
+	return s.ReturnedGoType(name, l)
+}
+
+func listItemType(s *Schema, name string, l *Link) (string, error) {
+	return newGenerator().listItemType(s, name, l)
 }
 
 func required(n string, def *Schema) bool {
@@ -158,19 +189,35 @@ func values(n string, s *Schema, l *Link) string {
 	return strings.Join(v, ", ")
 }
 
-func params(name string, l *Link) string {
+func params(name string, l *Link) (string, error) {
+	return newGenerator().params(name, l)
+}
+
+func paramList(name string, l *Link) ([]param, error) {
+	return newGenerator().paramList(name, l)
+}
+
+// paramNames returns the names of the parameters of the method generated
+// for a link, as passed on to another call.
+func paramNames(name string, l *Link) (string, error) {
+	order, _, err := l.Parameters(name)
+	if err != nil {
+		return "", err
+	}
 	var p []string
-	order, params := l.Parameters(name)
 	for _, n := range order {
-		p = append(p, fmt.Sprintf("%s %s", initialLow(n), params[n]))
+		p = append(p, initialLow(n))
 	}
-	return strings.Join(p, ", ")
+	return strings.Join(p, ", "), nil
 }
 
-func requestParams(l *Link) string {
-	_, params := l.Parameters("")
+func requestParams(l *Link) (string, error) {
+	_, params, err := l.Parameters("")
+	if err != nil {
+		return "", err
+	}
 	if strings.ToUpper(l.Method) == "DELETE" {
-		return ""
+		return "", nil
 	}
 	p := []string{""}
 	if _, ok := params["o"]; ok {
@@ -183,7 +230,17 @@ func requestParams(l *Link) string {
 	} else if strings.ToUpper(l.Method) == "GET" {
 		p = append(p, "nil")
 	}
-	return strings.Join(p, ", ")
+	return strings.Join(p, ", "), nil
+}
+
+// route returns the regular expression matching the paths of a href, its
+// variables being captured.
+func route(h *HRef) string {
+	parts := strings.Split(h.String(), "%v")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return "^" + strings.Join(parts, "([^/]+)") + "$"
 }
 
 func args(h *HRef) string {
@@ -205,6 +262,49 @@ func returnType(name string, s *Schema, l *Link) string {
 	return initialCap(name)
 }
 
+// enumConstant returns the name of the constant declared for an enum value.
+func enumConstant(name, value string) string {
+	value = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '-'
+	}, value)
+	if strings.Trim(value, "-") == "" {
+		value = "empty"
+	}
+	return name + initialCap(value)
+}
+
+// enumValues returns the values of an enum, without duplicates.
+func enumValues(values []string) []string {
+	var unique []string
+	for _, v := range values {
+		if !contains(v, unique) {
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+// enumConstants returns the names of the constants declared for the values
+// of an enum, suffixed by a number when values only differing by their case
+// or punctuation would share one.
+func enumConstants(name string, values []string) []string {
+	consts := make([]string, len(values))
+	used := make(map[string]bool)
+	for i, v := range values {
+		c := enumConstant(name, v)
+		base := c
+		for n := 2; used[c]; n++ {
+			c = fmt.Sprintf("%s%d", base, n)
+		}
+		used[c] = true
+		consts[i] = c
+	}
+	return consts
+}
+
 func paramType(name string, l *Link) string {
 	if l.AcceptsCustomType() {
 		return initialCap(fmt.Sprintf("%s-%s-Opts", name, l.Title))
@@ -103,3 +103,91 @@ func TestAsComment(t *testing.T) {
 		}
 	}
 }
+
+var enumConstantTests = []struct {
+	Value    string
+	Constant string
+}{
+	{
+		Value:    "hobby-dev",
+		Constant: "PlanHobbyDev",
+	},
+	{
+		Value:    "us/east 1",
+		Constant: "PlanUsEast1",
+	},
+	{
+		Value:    "",
+		Constant: "PlanEmpty",
+	},
+}
+
+func TestEnumConstant(t *testing.T) {
+	for i, ect := range enumConstantTests {
+		c := enumConstant("Plan", ect.Value)
+		if c != ect.Constant {
+			t.Errorf("%d: wants %v, got %v", i, ect.Constant, c)
+		}
+	}
+}
+
+var enumConstantsTests = []struct {
+	Values    []string
+	Constants []string
+}{
+	{
+		Values:    []string{"hobby-dev", "standard-0"},
+		Constants: []string{"PlanHobbyDev", "PlanStandard0"},
+	},
+	{
+		Values:    []string{"foo-bar", "foo_bar", "Foo Bar"},
+		Constants: []string{"PlanFooBar", "PlanFooBar2", "PlanFooBar3"},
+	},
+}
+
+func TestEnumValues(t *testing.T) {
+	values := enumValues([]string{"hobby", "basic", "hobby"})
+	if want := []string{"hobby", "basic"}; !equalStrings(values, want) {
+		t.Errorf("wants %v, got %v", want, values)
+	}
+}
+
+func TestEnumConstants(t *testing.T) {
+	for i, ect := range enumConstantsTests {
+		c := enumConstants("Plan", ect.Values)
+		if !equalStrings(c, ect.Constants) {
+			t.Errorf("%d: wants %v, got %v", i, ect.Constants, c)
+		}
+	}
+}
+
+var routeTests = []struct {
+	HRef  string
+	Route string
+}{
+	{
+		HRef:  "/apps",
+		Route: `^/apps$`,
+	},
+	{
+		HRef:  "/apps/{(%23%2Fdefinitions%2Fapp%2Fdefinitions%2Fidentity)}/config-vars",
+		Route: `^/apps/([^/]+)/config-vars$`,
+	},
+	{
+		HRef:  "/apps/{(%23%2Fdefinitions%2Fapp%2Fdefinitions%2Fidentity)}/addons/{(%23%2Fdefinitions%2Faddon%2Fdefinitions%2Fidentity)}",
+		Route: `^/apps/([^/]+)/addons/([^/]+)$`,
+	},
+	{
+		HRef:  "/v1.0/search",
+		Route: `^/v1\.0/search$`,
+	},
+}
+
+func TestRoute(t *testing.T) {
+	for i, rt := range routeTests {
+		r := route(NewHRef(rt.HRef))
+		if r != rt.Route {
+			t.Errorf("%d: wants %v, got %v", i, rt.Route, r)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package schematic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LintRule is a design rule schemas are checked against, following the
+// Heroku HTTP API design guide.
+type LintRule struct {
+	Name        string
+	Description string
+	// Warning is true for rules whose violations are only reported as
+	// warnings.
+	Warning bool
+
+	check func(l *linter, r *lintResource)
+}
+
+// LintProblem is a violation of a lint rule.
+type LintProblem struct {
+	Rule    string `json:"rule"`
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+	Warning bool   `json:"warning,omitempty"`
+}
+
+func (p *LintProblem) String() string {
+	msg := fmt.Sprintf("%s: %s (%s)", p.Pointer, p.Message, p.Rule)
+	if p.Warning {
+		msg = "warning: " + msg
+	}
+	return msg
+}
+
+var lintRules = []*LintRule{
+	{
+		Name:        "resource-attributes",
+		Description: "resources have id, created_at and updated_at properties",
+		check:       lintResourceAttributes,
+	},
+	{
+		Name:        "property-description",
+		Description: "properties have a description",
+		check: func(l *linter, r *lintResource) {
+			l.properties(r, func(p *Schema, pointer string) {
+				if p.Description == "" {
+					l.report(pointer, "missing description")
+				}
+			})
+		},
+	},
+	{
+		Name:        "property-example",
+		Description: "properties have an example",
+		check: func(l *linter, r *lintResource) {
+			l.properties(r, func(p *Schema, pointer string) {
+				// Objects are exemplified by their properties.
+				if p.Example == nil && !p.IsCustomType() {
+					l.report(pointer, "missing example")
+				}
+			})
+		},
+	},
+	{
+		Name:        "link-title-case",
+		Description: "link titles are lowercase",
+		check: func(l *linter, r *lintResource) {
+			for i, link := range r.Schema.Links {
+				if link.Title != strings.ToLower(link.Title) {
+					l.report(pointerTo(r.Pointer, "links", strconv.Itoa(i), "title"), "title %q is not lowercase", link.Title)
+				}
+			}
+		},
+	},
+	{
+		Name:        "identity",
+		Description: "identity definitions are an anyOf or oneOf of id and name",
+		check:       lintIdentity,
+	},
+	{
+		Name:        "read-only-required",
+		Description: "links don't require read-only properties, left out of requests",
+		Warning:     true,
+		check:       lintReadOnlyRequired,
+	},
+}
+
+// LintRules returns the available lint rules.
+func LintRules() []*LintRule {
+	return append([]*LintRule(nil), lintRules...)
+}
+
+// Lint checks the resources of the schema, before it is resolved, against
+// the named rules, or all of them if none is given. Problems are returned
+// resource by resource, in the order of the rules.
+func (s *Schema) Lint(rules ...string) ([]*LintProblem, error) {
+	enabled := lintRules
+	if len(rules) > 0 {
+		enabled = nil
+		for _, name := range rules {
+			r := lookupLintRule(name)
+			if r == nil {
+				return nil, fmt.Errorf("unknown lint rule %q", name)
+			}
+			enabled = append(enabled, r)
+		}
+	}
+
+	l := &linter{root: s}
+	for _, name := range sortedKeys(s.Properties) {
+		r := l.resource(s.Properties[name], pointerTo(fragment, "properties", name))
+		if r.Schema.Links == nil && r.Schema.Properties == nil {
+			continue
+		}
+		for _, rule := range enabled {
+			l.rule = rule
+			rule.check(l, r)
+		}
+	}
+	return l.problems, nil
+}
+
+func lookupLintRule(name string) *LintRule {
+	for _, r := range lintRules {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// lintResource is a resource, once its references are followed.
+type lintResource struct {
+	Schema  *Schema
+	Doc     *Schema
+	Pointer string
+}
+
+type linter struct {
+	root     *Schema
+	rule     *LintRule
+	problems []*LintProblem
+}
+
+func (l *linter) report(pointer string, format string, a ...interface{}) {
+	l.problems = append(l.problems, &LintProblem{
+		Rule:    l.rule.Name,
+		Pointer: pointer,
+		Message: fmt.Sprintf(format, a...),
+		Warning: l.rule.Warning,
+	})
+}
+
+// resource follows the references of s, defined at the given pointer of
+// the root document. Unresolved references are left to Validate.
+func (l *linter) resource(s *Schema, pointer string) *lintResource {
+	return l.follow(&lintResource{Schema: s, Doc: l.root, Pointer: pointer})
+}
+
+func (l *linter) follow(r *lintResource) *lintResource {
+	seen := make(map[*Schema]bool)
+	for r.Schema.Ref != nil && !seen[r.Schema] {
+		seen[r.Schema] = true
+		t, d, err := r.Schema.Ref.resolve(r.Doc)
+		if err != nil {
+			break
+		}
+		r = &lintResource{Schema: t, Doc: d, Pointer: string(*r.Schema.Ref)}
+	}
+	return r
+}
+
+// properties calls f with the properties of the resource, and those of its
+// nested objects, once their references are followed.
+func (l *linter) properties(r *lintResource, f func(p *Schema, pointer string)) {
+	rs := ResolvedSet{}
+	var walk func(r *lintResource)
+	walk = func(r *lintResource) {
+		if rs.Has(r.Schema) {
+			return
+		}
+		rs.Insert(r.Schema)
+		for _, name := range sortedKeys(r.Schema.Properties) {
+			pointer := pointerTo(r.Pointer, "properties", name)
+			p := l.follow(&lintResource{Schema: r.Schema.Properties[name], Doc: r.Doc, Pointer: pointer})
+			// Descriptions and examples can be set along the reference.
+			merged := *p.Schema
+			if ps := r.Schema.Properties[name]; ps != p.Schema {
+				if ps.Description != "" {
+					merged.Description = ps.Description
+				}
+				if ps.Example != nil {
+					merged.Example = ps.Example
+				}
+			}
+			f(&merged, pointer)
+			walk(p)
+		}
+	}
+	walk(r)
+}
+
+func lintResourceAttributes(l *linter, r *lintResource) {
+	var missing []string
+	for _, name := range []string{"id", "created_at", "updated_at"} {
+		if _, ok := r.Schema.Properties[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		l.report(r.Pointer, "missing %s properties", strings.Join(missing, ", "))
+	}
+}
+
+func lintIdentity(l *linter, r *lintResource) {
+	identity, ok := r.Schema.Definitions["identity"]
+	if !ok {
+		return
+	}
+	pointer := pointerTo(r.Pointer, "definitions", "identity")
+	branches := identity.AnyOf
+	if branches == nil {
+		branches = identity.OneOf
+	}
+	refs := make(map[string]bool)
+	for _, b := range branches {
+		if b.Ref == nil {
+			continue
+		}
+		parts := strings.Split(string(*b.Ref), separator)
+		refs[parts[len(parts)-1]] = true
+	}
+	if !refs["id"] || !refs["name"] {
+		l.report(pointer, "identity is not an anyOf or oneOf of id and name")
+	}
+}
+
+func lintReadOnlyRequired(l *linter, r *lintResource) {
+	for i, link := range r.Schema.Links {
+		if link.Schema == nil {
+			continue
+		}
+		s := l.follow(&lintResource{Schema: link.Schema, Doc: r.Doc, Pointer: pointerTo(r.Pointer, "links", strconv.Itoa(i), "schema")})
+		for _, name := range s.Schema.Required {
+			ps, ok := s.Schema.Properties[name]
+			if !ok {
+				continue
+			}
+			pointer := pointerTo(s.Pointer, "properties", name)
+			if ps.ReadOnly || l.follow(&lintResource{Schema: ps, Doc: s.Doc, Pointer: pointer}).Schema.ReadOnly {
+				l.report(pointer, "read-only property %s is required", name)
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package schematic
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var lintTests = []struct {
+	Rules    []string
+	Schema   string
+	Problems []string
+}{
+	{
+		Schema: `{
+			"definitions": {"app": {
+				"definitions": {
+					"id": {"type": "string", "description": "unique identifier", "example": "01234567"},
+					"name": {"type": "string", "description": "unique name", "example": "example"},
+					"identity": {"anyOf": [{"$ref": "#/definitions/app/definitions/id"}, {"$ref": "#/definitions/app/definitions/name"}]},
+					"created_at": {"type": "string", "description": "when created", "example": "2012-01-01T12:00:00Z"}
+				},
+				"properties": {
+					"id": {"$ref": "#/definitions/app/definitions/id"},
+					"name": {"$ref": "#/definitions/app/definitions/name"},
+					"created_at": {"$ref": "#/definitions/app/definitions/created_at"},
+					"updated_at": {"$ref": "#/definitions/app/definitions/created_at", "description": "when updated"}
+				},
+				"links": [{"title": "info", "href": "/apps"}]
+			}},
+			"properties": {"app": {"$ref": "#/definitions/app"}}
+		}`,
+		Problems: nil,
+	},
+	{
+		Schema: `{"properties": {"app": {
+			"definitions": {"identity": {"$ref": "#/properties/app/definitions/id"}},
+			"properties": {
+				"id": {"type": "string", "description": "unique identifier"},
+				"owner": {"type": "object", "properties": {"email": {"type": "string", "example": "a@b.c"}}}
+			},
+			"links": [{"title": "Info", "href": "/apps"}]
+		}}}`,
+		Problems: []string{
+			"resource-attributes #/properties/app",
+			"property-description #/properties/app/properties/owner",
+			"property-description #/properties/app/properties/owner/properties/email",
+			"property-example #/properties/app/properties/id",
+			"link-title-case #/properties/app/links/0/title",
+			"identity #/properties/app/definitions/identity",
+		},
+	},
+	{
+		Rules: []string{"link-title-case"},
+		Schema: `{"properties": {"app": {
+			"properties": {"id": {"type": "string"}},
+			"links": [{"title": "info", "href": "/apps"}, {"title": "List", "href": "/apps"}]
+		}}}`,
+		Problems: []string{
+			"link-title-case #/properties/app/links/1/title",
+		},
+	},
+	{
+		Rules: []string{"read-only-required"},
+		Schema: `{"properties": {"app": {
+			"definitions": {"id": {"type": "string", "readOnly": true}},
+			"properties": {"id": {"$ref": "#/properties/app/definitions/id"}, "name": {"type": "string"}},
+			"links": [{"title": "create", "href": "/apps", "schema": {
+				"properties": {"id": {"$ref": "#/properties/app/definitions/id"}, "name": {"type": "string"}},
+				"required": ["id", "name"]
+			}}]
+		}}}`,
+		Problems: []string{
+			"read-only-required #/properties/app/links/0/schema/properties/id",
+		},
+	},
+}
+
+func TestLint(t *testing.T) {
+	for i, lt := range lintTests {
+		var s Schema
+		if err := json.Unmarshal([]byte(lt.Schema), &s); err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		problems, err := s.Lint(lt.Rules...)
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		var found []string
+		for _, p := range problems {
+			found = append(found, p.Rule+" "+p.Pointer)
+		}
+		if !reflect.DeepEqual(found, lt.Problems) {
+			t.Errorf("%d: wants %v, got %v", i, lt.Problems, found)
+		}
+	}
+}
+
+func TestLintUnknownRule(t *testing.T) {
+	if _, err := new(Schema).Lint("unknown"); err == nil {
+		t.Errorf("expected an error for an unknown rule")
+	}
+}
@@ -0,0 +1,122 @@
+package schematic
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Loader loads the schema documents targeted by external references.
+type Loader interface {
+	// Load returns the schema document found at location.
+	Load(location string) (*Schema, error)
+}
+
+// FileLoader loads schema documents from the file system.
+type FileLoader struct{}
+
+// Load decodes the JSON schema document stored in the named file.
+func (FileLoader) Load(location string) (*Schema, error) {
+	f, err := os.Open(location)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var s Schema
+	if err := json.NewDecoder(f).Decode(&s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Load loads the schema document found at location. References to other
+// documents are then loaded through l, relative to the referencing
+// document location.
+func Load(location string, l Loader) (*Schema, error) {
+	s, err := l.Load(location)
+	if err != nil {
+		return nil, err
+	}
+	s.SetLoader(location, l)
+	return s, nil
+}
+
+// SetLoader marks s as the root document found at location, so references
+// to other documents are loaded through l relative to it.
+func (s *Schema) SetLoader(location string, l Loader) {
+	s.location = clean(location)
+	s.documents = &documents{
+		loader: l,
+		loaded: map[string]*Schema{s.location: s},
+	}
+}
+
+// documents caches the documents loaded while resolving a schema, so each
+// one is only loaded and resolved once.
+type documents struct {
+	loader Loader
+	loaded map[string]*Schema
+}
+
+func (d *documents) load(location string) (*Schema, error) {
+	if s, ok := d.loaded[location]; ok {
+		return s, nil
+	}
+	s, err := d.loader.Load(location)
+	if err != nil {
+		return nil, err
+	}
+	s.location = location
+	s.documents = d
+	d.loaded[location] = s
+	return s, nil
+}
+
+// locate returns the location of the document targeted from the document
+// found at base.
+func locate(base, target string) string {
+	if u, err := url.Parse(target); err == nil && u.IsAbs() {
+		return target
+	}
+	if b, err := url.Parse(base); err == nil && b.IsAbs() {
+		if u, err := url.Parse(target); err == nil {
+			return b.ResolveReference(u).String()
+		}
+	}
+	if filepath.IsAbs(target) {
+		return filepath.Clean(target)
+	}
+	return clean(filepath.Join(filepath.Dir(base), target))
+}
+
+func clean(location string) string {
+	if u, err := url.Parse(location); err == nil && u.IsAbs() {
+		return location
+	}
+	return filepath.Clean(location)
+}
+
+// split splits a reference into the location of the targeted document and
+// the pointer inside it.
+func (rf Reference) split() (location, pointer string) {
+	if i := strings.Index(string(rf), fragment); i != -1 {
+		return string(rf[:i]), string(rf[i:])
+	}
+	return string(rf), fragment
+}
+
+// target returns the pointer of the schema targeted by the reference, made
+// from the element at the given pointer and resolved in document d.
+func (rf Reference) target(d *Schema, from string) string {
+	location, pointer := rf.split()
+	if location != "" {
+		return d.location + pointer
+	}
+	if i := strings.Index(from, fragment); i != -1 {
+		return from[:i] + pointer
+	}
+	return pointer
+}
@@ -0,0 +1,135 @@
+package schematic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// mapLoader loads documents from memory.
+type mapLoader map[string]string
+
+func (m mapLoader) Load(location string) (*Schema, error) {
+	doc, ok := m[location]
+	if !ok {
+		return nil, fmt.Errorf("no document at %s", location)
+	}
+	var s Schema
+	if err := json.Unmarshal([]byte(doc), &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+var locateTests = []struct {
+	Base     string
+	Target   string
+	Location string
+}{
+	{
+		Base:     "schema.json",
+		Target:   "app.json",
+		Location: "app.json",
+	},
+	{
+		Base:     "schemata/app.json",
+		Target:   "./common/types.json",
+		Location: "schemata/common/types.json",
+	},
+	{
+		Base:     "schemata/app.json",
+		Target:   "../schema.json",
+		Location: "schema.json",
+	},
+	{
+		Base:     "https://example.com/schemata/app.json",
+		Target:   "types.json",
+		Location: "https://example.com/schemata/types.json",
+	},
+}
+
+func TestLocate(t *testing.T) {
+	for i, lt := range locateTests {
+		if l := locate(lt.Base, lt.Target); l != lt.Location {
+			t.Errorf("%d: wants %v, got %v", i, lt.Location, l)
+		}
+	}
+}
+
+func TestLoadExternalReferences(t *testing.T) {
+	loader := mapLoader{
+		"schema.json": `{
+			"title": "Account Manager",
+			"properties": {"account": {"$ref": "schemata/account.json"}}
+		}`,
+		"schemata/account.json": `{
+			"type": "object",
+			"definitions": {"id": {"$ref": "./common/types.json#/definitions/uuid"}},
+			"properties": {"id": {"$ref": "#/definitions/id"}},
+			"links": [{
+				"title": "Info",
+				"rel": "self",
+				"method": "GET",
+				"href": "/accounts/{(%23%2Fdefinitions%2Faccount%2Fdefinitions%2Fid)}"
+			}]
+		}`,
+		"schemata/common/types.json": `{
+			"definitions": {"uuid": {"type": "string", "format": "uuid"}}
+		}`,
+	}
+	s, err := Load("schema.json", loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Generate()
+	var se *SchemaError
+	if !errors.As(err, &se) || !strings.HasPrefix(se.Pointer, "schemata/account.json#/links/0") {
+		t.Fatalf("wants an error for the account href, got %v", err)
+	}
+
+	loader["schemata/account.json"] = strings.Replace(loader["schemata/account.json"], "%23%2Fdefinitions%2Faccount", "%23", 1)
+	s, err = Load("schema.json", loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := s.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "func (s *Service) AccountInfo(") {
+		t.Errorf("expected AccountInfo to be generated")
+	}
+}
+
+func TestLoadReferenceCycle(t *testing.T) {
+	loader := mapLoader{
+		"a.json": `{
+			"title": "Cycle",
+			"properties": {"b": {"$ref": "b.json#/definitions/b"}},
+			"definitions": {"a": {"$ref": "b.json#/definitions/b"}}
+		}`,
+		"b.json": `{"definitions": {"b": {"$ref": "a.json#/definitions/a"}}}`,
+	}
+	s, err := Load("a.json", loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Resolve(nil, ResolvedSet{}); err == nil {
+		t.Fatal("expected a reference cycle error")
+	}
+}
+
+func TestResolveExternalReferenceWithoutLoader(t *testing.T) {
+	s := &Schema{
+		Properties: map[string]*Schema{
+			"account": {
+				Ref: NewReference("account.json"),
+			},
+		},
+	}
+	if _, err := s.Resolve(nil, ResolvedSet{}); err == nil {
+		t.Fatal("expected an error")
+	}
+}
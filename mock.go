@@ -0,0 +1,183 @@
+package schematic
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// mockRoute describes how the mock server answers the requests of a link.
+type mockRoute struct {
+	Method   string
+	Route    string
+	Status   int
+	Response string
+	Schema   string
+}
+
+// mockRule is the subset of a link schema the mock server validates request
+// bodies against.
+type mockRule struct {
+	Type       []string             `json:",omitempty"`
+	Enum       []string             `json:",omitempty"`
+	Required   []string             `json:",omitempty"`
+	Properties map[string]*mockRule `json:",omitempty"`
+	Items      *mockRule            `json:",omitempty"`
+	Branches   []*mockRule          `json:",omitempty"`
+}
+
+// GenerateMock generates a package serving a fake version of the API for
+// tests, named after the client package suffixed by "mock". Every link is
+// answered with a response built from the examples of the schema, once its
+// request body is validated against the link schema.
+func (s *Schema) GenerateMock(opts Options) ([]byte, error) {
+	var buf bytes.Buffer
+
+	g, s, err := s.generator(opts)
+	if err != nil {
+		return nil, err
+	}
+	var routes []mockRoute
+	for _, name := range s.resources() {
+		schema := s.Properties[name]
+		for _, l := range schema.Links {
+			r, err := l.mockRoute(schema)
+			if err != nil {
+				return nil, err
+			}
+			routes = append(routes, r)
+		}
+	}
+	if err := g.execute(&buf, "mock.tmpl", struct {
+		Package string
+		Name    string
+		Routes  []mockRoute
+	}{
+		Package: g.opts.Package + "mock",
+		Name:    g.opts.Package,
+		Routes:  routes,
+	}); err != nil {
+		return nil, err
+	}
+	return g.format(buf.Bytes(), false)
+}
+
+// mockRoute returns the route answering the link of the given resource.
+func (l *Link) mockRoute(s *Schema) (mockRoute, error) {
+	if l.HRef == nil {
+		return mockRoute{}, schemaErrorf(fragment, "no href property declared for %s", l.Title)
+	}
+	r := mockRoute{
+		Method: strings.ToUpper(l.Method),
+		Route:  route(l.HRef),
+		Status: http.StatusOK,
+	}
+	if l.Rel == "create" {
+		r.Status = http.StatusCreated
+	}
+	if !s.EmptyResult(l) {
+		target := s
+		if l.TargetSchema != nil {
+			target = l.TargetSchema
+		}
+		response, err := json.Marshal(target.example(ResolvedSet{}))
+		if err != nil {
+			return r, err
+		}
+		r.Response = string(response)
+	}
+	if l.Schema != nil && r.Method != "GET" {
+		schema, err := json.Marshal(l.Schema.mockRule(ResolvedSet{}))
+		if err != nil {
+			return r, err
+		}
+		r.Schema = string(schema)
+	}
+	return r, nil
+}
+
+// example returns an example value for the schema, built from the examples
+// of its properties if it has none.
+func (s *Schema) example(rs ResolvedSet) interface{} {
+	if s.Example != nil {
+		return s.Example
+	}
+	if rs.Has(s) {
+		return nil
+	}
+	rs.Insert(s)
+	defer delete(rs, s)
+
+	if s.IsUnion() {
+		return s.Branches()[0].example(rs)
+	}
+	if len(s.Enum) > 0 {
+		return s.Enum[0]
+	}
+	types, _ := s.Types()
+	for _, t := range types {
+		switch t {
+		case "object":
+			v := make(map[string]interface{})
+			for _, name := range sortedKeys(s.Properties) {
+				v[name] = s.Properties[name].example(rs)
+			}
+			return v
+		case "array":
+			if s.Items == nil {
+				return []interface{}{}
+			}
+			return []interface{}{s.Items.example(rs)}
+		case "string":
+			return formatExamples[s.Format]
+		case "integer", "number":
+			return 0
+		case "boolean":
+			return false
+		}
+	}
+	return nil
+}
+
+// formatExamples are the default examples of the string formats, which
+// would not accept an empty string.
+var formatExamples = map[string]string{
+	"date-time": "2012-01-01T12:00:00Z",
+	"date":      "2012-01-01",
+	"duration":  "PT0S",
+	"uuid":      "01234567-89ab-cdef-0123-456789abcdef",
+	"uri":       "https://example.com",
+	"email":     "username@example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+}
+
+// mockRule returns the rule validating values of the schema. Recursive
+// schemas are only validated down to their first repetition.
+func (s *Schema) mockRule(rs ResolvedSet) *mockRule {
+	if rs.Has(s) {
+		return nil
+	}
+	rs.Insert(s)
+	defer delete(rs, s)
+
+	r := &mockRule{Enum: s.Enum, Required: s.Required}
+	if s.IsUnion() {
+		for _, b := range s.allBranches() {
+			r.Branches = append(r.Branches, b.mockRule(rs))
+		}
+		return r
+	}
+	r.Type, _ = s.Types()
+	if len(s.Properties) > 0 {
+		r.Properties = make(map[string]*mockRule)
+		for name, p := range s.Properties {
+			r.Properties[name] = p.mockRule(rs)
+		}
+	}
+	if s.Items != nil {
+		r.Items = s.Items.mockRule(rs)
+	}
+	return r
+}
@@ -0,0 +1,89 @@
+package schematic
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var exampleTests = []struct {
+	Schema   *Schema
+	Expected interface{}
+}{
+	{
+		Schema:   &Schema{Type: "string", Example: "example"},
+		Expected: "example",
+	},
+	{
+		Schema:   &Schema{Type: "string", Enum: []string{"cedar", "fir"}},
+		Expected: "cedar",
+	},
+	{
+		Schema:   &Schema{Type: []interface{}{"null", "integer"}},
+		Expected: 0,
+	},
+	{
+		Schema: &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"name": {Type: "string", Example: "example"},
+				"tags": {Type: "array", Items: &Schema{Type: "string", Example: "web"}},
+			},
+		},
+		Expected: map[string]interface{}{
+			"name": "example",
+			"tags": []interface{}{"web"},
+		},
+	},
+	{
+		Schema: &Schema{
+			OneOf: []*Schema{
+				{Type: "integer", Example: 42},
+				{Type: "string", Example: "42"},
+			},
+		},
+		Expected: 42,
+	},
+}
+
+func TestExample(t *testing.T) {
+	for i, et := range exampleTests {
+		example := et.Schema.example(ResolvedSet{})
+		if !reflect.DeepEqual(example, et.Expected) {
+			t.Errorf("%d: wants %v, got %v", i, et.Expected, example)
+		}
+	}
+}
+
+func TestExampleCycle(t *testing.T) {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	s.Properties["parent"] = s
+	expected := map[string]interface{}{"parent": nil}
+	if example := s.example(ResolvedSet{}); !reflect.DeepEqual(example, expected) {
+		t.Errorf("wants %v, got %v", expected, example)
+	}
+}
+
+func TestGenerateMock(t *testing.T) {
+	src, err := generateTests[0].Schema.GenerateMock(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name.Name != "accountmock" {
+		t.Errorf("wants package accountmock, got %s", f.Name.Name)
+	}
+	for _, name := range []string{"NewServer", "Handler"} {
+		if f.Scope.Lookup(name) == nil {
+			t.Errorf("expected %s to be declared", name)
+		}
+	}
+	if !strings.Contains(string(src), `regexp.MustCompile("^/accounts$")`) {
+		t.Errorf("expected a route for /accounts")
+	}
+}
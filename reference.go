@@ -3,6 +3,7 @@ package schematic
 import (
 	"fmt"
 	"net/url"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"strings"
@@ -26,23 +27,42 @@ func NewReference(ref string) *Reference {
 }
 
 // Resolve resolves reference inside a Schema.
-func (rf Reference) Resolve(r *Schema) *Schema {
-	if !strings.HasPrefix(string(rf), fragment) {
-		panic(fmt.Sprintf("non-fragment reference are not supported : %s", rf))
+func (rf Reference) Resolve(r *Schema) (*Schema, error) {
+	s, _, err := rf.resolve(r)
+	return s, err
+}
+
+// resolve resolves the reference from the document r, loading the targeted
+// document if needed. It returns the targeted schema and its document.
+func (rf Reference) resolve(r *Schema) (*Schema, *Schema, error) {
+	location, pointer := rf.split()
+	if location != "" {
+		if r.documents == nil {
+			return nil, nil, schemaErrorf(string(rf), "non-fragment reference are not supported without a loader")
+		}
+		var err error
+		if r, err = r.documents.load(locate(r.location, location)); err != nil {
+			return nil, nil, &SchemaError{Pointer: string(rf), Err: err}
+		}
 	}
+	s, err := rf.follow(r, pointer)
+	return s, r, err
+}
+
+// follow follows the given JSON pointer inside the document r.
+func (rf Reference) follow(r *Schema, pointer string) (*Schema, error) {
 	var node interface{}
 	node = r
-	for _, t := range strings.Split(string(rf), separator)[1:] {
+	for _, t := range strings.Split(pointer, separator)[1:] {
 		t = decode(t)
 		v := reflect.Indirect(reflect.ValueOf(node))
 		switch v.Kind() {
 		case reflect.Struct:
 			var f reflect.Value
 			for i := 0; i < v.NumField(); i++ {
-				f = v.Field(i)
 				ft := v.Type().Field(i)
 				tag := ft.Tag.Get("json")
-				if tag == "-" {
+				if tag == "-" || ft.PkgPath != "" {
 					continue
 				}
 				name := parseTag(tag)
@@ -50,29 +70,34 @@ func (rf Reference) Resolve(r *Schema) *Schema {
 					name = ft.Name
 				}
 				if name == t {
+					f = v.Field(i)
 					break
 				}
 			}
 			if !f.IsValid() {
-				panic(fmt.Sprintf("can't find '%s' field in %s", t, rf))
+				return nil, schemaErrorf(string(rf), "can't find '%s' field", t)
 			}
 			node = f.Interface()
 		case reflect.Map:
 			kv := v.MapIndex(reflect.ValueOf(t))
 			if !kv.IsValid() {
-				panic(fmt.Sprintf("can't find '%s' key in %s", t, rf))
+				return nil, schemaErrorf(string(rf), "can't find '%s' key", t)
 			}
 			node = kv.Interface()
 		default:
-			panic(fmt.Sprintf("can't follow pointer : %s", rf))
+			return nil, schemaErrorf(string(rf), "can't follow pointer")
 		}
 	}
-	return node.(*Schema)
+	s, ok := node.(*Schema)
+	if !ok || s == nil {
+		return nil, schemaErrorf(string(rf), "does not point to a schema")
+	}
+	return s, nil
 }
 
 func encode(t string) (encoded string) {
-	encoded = strings.Replace(t, "/", "~1", -1)
-	return strings.Replace(encoded, "~", "~0", -1)
+	encoded = strings.Replace(t, "~", "~0", -1)
+	return strings.Replace(encoded, "/", "~1", -1)
 }
 
 func decode(t string) (decoded string) {
@@ -102,19 +127,41 @@ func NewHRef(href string) *HRef {
 }
 
 // Resolve resolves a href inside a Schema.
-func (h *HRef) Resolve(r *Schema, rs ResolvedSet) {
+func (h *HRef) Resolve(r *Schema, rs ResolvedSet) error {
 	h.Order = make([]string, 0)
 	h.Schemas = make(map[string]*Schema)
 	for _, v := range href.FindAllString(string(h.href), -1) {
 		u, err := url.QueryUnescape(v[2 : len(v)-2])
 		if err != nil {
-			panic(err)
+			return err
+		}
+		s, d, err := Reference(u).resolve(r)
+		if err != nil {
+			return err
 		}
 		parts := strings.Split(u, "/")
-		name := initialLow(fmt.Sprintf("%s-%s", parts[len(parts)-3], parts[len(parts)-1]))
+		if len(parts) < 3 {
+			return fmt.Errorf("can't name href variable %s", u)
+		}
+		name := parts[len(parts)-1]
+		prefix := parts[len(parts)-3]
+		if strings.HasSuffix(prefix, fragment) {
+			// Defined at the top of a document, named after the document.
+			prefix = ""
+			if d.location != "" {
+				prefix = strings.TrimSuffix(filepath.Base(d.location), filepath.Ext(d.location))
+			}
+		}
+		if prefix != "" {
+			name = fmt.Sprintf("%s-%s", prefix, name)
+		}
+		name = initialLow(name)
 		h.Order = append(h.Order, name)
-		h.Schemas[name] = Reference(u).Resolve(r).Resolve(r, rs)
+		if h.Schemas[name], err = s.resolve(d, rs, Reference(u).target(d, fragment)); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 // UnmarshalJSON sets *h to a copy of data.
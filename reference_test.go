@@ -45,13 +45,31 @@ var refResolveTests = []struct {
 func TestReferenceResolve(t *testing.T) {
 	for i, rt := range refResolveTests {
 		ref := Reference(rt.Ref)
-		rsl := ref.Resolve(rt.Schema)
+		rsl, err := ref.Resolve(rt.Schema)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+		}
 		if !reflect.DeepEqual(rsl, rt.Resolved) {
 			t.Errorf("%d: resolved schema don't match, got %v, wants %v", i, rsl, rt.Resolved)
 		}
 	}
 }
 
+func TestReferenceResolveError(t *testing.T) {
+	schema := &Schema{
+		Definitions: map[string]*Schema{
+			"uuid": {
+				Title: "Identifier",
+			},
+		},
+	}
+	for _, ref := range []string{"#/definitions/id", "#/unknown", "app.json#/definitions/uuid"} {
+		if _, err := Reference(ref).Resolve(schema); err == nil {
+			t.Errorf("%s: expected an error", ref)
+		}
+	}
+}
+
 var hrefTests = []struct {
 	HRef     string
 	Schema   *Schema
@@ -113,7 +131,9 @@ var hrefTests = []struct {
 func TestHREfResolve(t *testing.T) {
 	for i, ht := range hrefTests {
 		href := NewHRef(ht.HRef)
-		href.Resolve(ht.Schema, ResolvedSet{})
+		if err := href.Resolve(ht.Schema, ResolvedSet{}); err != nil {
+			t.Errorf("%d: %v", i, err)
+		}
 		if !reflect.DeepEqual(href.Order, ht.Order) {
 			t.Errorf("%d: resolved order don't match, got %v, wants %v", i, href.Order, ht.Order)
 		}
@@ -20,11 +20,12 @@ type Schema struct {
 	Definitions map[string]*Schema `json:"definitions,omitempty"`
 
 	// Numbers
-	MultipleOf       float64 `json:"multipleOf,omitempty"`
-	Maximum          float64 `json:"maximum,omitempty"`
-	ExclusiveMaximum bool    `json:"exclusiveMaximum,omitempty"`
-	Minimum          float64 `json:"minimum,omitempty"`
-	ExclusiveMinimum bool    `json:"exclusiveMinimum,omitempty"`
+	MultipleOf float64 `json:"multipleOf,omitempty"`
+	// Maximum and Minimum are nil when not set, telling a zero bound apart.
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMaximum bool     `json:"exclusiveMaximum,omitempty"`
+	Minimum          *float64 `json:"minimum,omitempty"`
+	ExclusiveMinimum bool     `json:"exclusiveMinimum,omitempty"`
 
 	// Strings
 	MinLength int    `json:"minLength,omitempty"`
@@ -50,14 +51,24 @@ type Schema struct {
 	// All
 	Enum []string `json:"enum,omitempty"`
 
-	// Schemas
-	OneOf []Schema `json:"oneOf,omitempty"`
-	AnyOf []Schema `json:"anyOf,omitempty"`
-	AllOf []Schema `json:"allOf,omitempty"`
-	Not   *Schema  `json:"not,omitempty"`
+	// Schemas are pointers, so that resolved branches are the definitions
+	// they reference.
+	OneOf []*Schema `json:"oneOf,omitempty"`
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+	AllOf []*Schema `json:"allOf,omitempty"`
+	Not   *Schema   `json:"not,omitempty"`
+
+	// Discriminator names the property whose value selects the oneOf or
+	// anyOf branch, based on the enum each branch declares for it.
+	Discriminator string `json:"discriminator,omitempty"`
 
 	// Links
 	Links []*Link `json:"links,omitempty"`
+
+	// Documents loaded with a Loader know where they come from, to resolve
+	// references to other documents.
+	location  string
+	documents *documents
 }
 
 // Link represents a Link description.
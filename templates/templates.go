@@ -2,21 +2,253 @@ package templates
 
 import "text/template"
 
-var templates = map[string]string{"field.tmpl": `{{fieldName .Name}} {{.Type}} {{fieldTag .Name .Required}} {{asComment .Definition.Description}}
+var templates = map[string]string{"enum.tmpl": `{{$Name := .Name}}
+{{$Values := enumValues .Definition.Enum}}
+{{$Consts := enumConstants $Name $Values}}
+{{asComment .Definition.Description}}
+type {{$Name}} string
+
+const (
+  {{range $i, $v := $Values}}
+  {{index $Consts $i}} {{$Name}} = {{printf "%q" $v}}
+  {{end}}
+)
+
+// Valid returns true if v is one of the enumerated values.
+func (v {{$Name}}) Valid() bool {
+  switch v {
+  case {{range $i, $v := $Values}}{{if $i}}, {{end}}{{index $Consts $i}}{{end}}:
+    return true
+  }
+  return false
+}
+`,
+	"fake.tmpl": `// Fake{{service}} is an in-memory {{service}}Interface for tests. Each method
+// calls the function field named after it, and fails if it isn't set.
+type Fake{{service}} struct {
+{{range .}}{{$Name := .Name}}{{$Def := .Definition}}
+  {{range .Definition.Links}}
+    {{printf "%s-%s-Func" $Name .Title | initialCap}} func(ctx context.Context, {{params $Name .}}) ({{values $Name $Def .}})
+  {{end}}
+{{end}}
+}
+
+var _ {{service}}Interface = (*Fake{{service}})(nil)
+
+{{range .}}{{$Name := .Name}}{{$Def := .Definition}}
+  {{range .Definition.Links}}
+    {{$Func := printf "%s-%s" $Name .Title | initialCap}}
+    // {{$Func}} calls {{$Func}}Func.
+    func (f *Fake{{service}}) {{$Func}}(ctx context.Context, {{params $Name .}}) ({{values $Name $Def .}}) {
+      if f.{{$Func}}Func == nil {
+        {{if ($Def.EmptyResult .)}}
+          return fmt.Errorf("Fake{{service}}.{{$Func}} is not implemented")
+        {{else}}
+          var v {{index ($Def.Values $Name .) 0}}
+          return v, fmt.Errorf("Fake{{service}}.{{$Func}} is not implemented")
+        {{end}}
+      }
+      return f.{{$Func}}Func(ctx, {{paramNames $Name .}})
+    }
+
+    {{if .Paginated}}{{if listItemType $Def $Name .}}
+      // {{$Func}}All iterates over the items returned by {{$Func}}Func.
+      func (f *Fake{{service}}) {{$Func}}All(ctx context.Context, {{params $Name .}}) *{{printf "%s-Iterator" $Func | initialCap}} {
+        page, err := f.{{$Func}}(ctx, {{paramNames $Name .}})
+        return &{{printf "%s-Iterator" $Func | initialCap}}{page: page, err: err}
+      }
+    {{end}}{{end}}
+  {{end}}
+{{end}}
+`,
+	"field.tmpl": `{{fieldName .Name}} {{.Type}} {{fieldTag .Name .Required}} {{asComment .Definition.Description}}
+`,
+	"formats.tmpl": `{{range .}}
+{{$T := .Name}}
+{{if eq .Kind "Date"}}
+// {{$T}} is a calendar date of the date format, e.g. "2006-01-02".
+type {{$T}} time.Time
+
+// New{{$T}} returns the date of the given day.
+func New{{$T}}(year int, month time.Month, day int) {{$T}} {
+	return {{$T}}(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+// Time returns the date at midnight UTC.
+func (d {{$T}}) Time() time.Time {
+	return time.Time(d)
+}
+
+func (d {{$T}}) String() string {
+	return time.Time(d).Format("2006-01-02")
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d {{$T}}) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *{{$T}}) UnmarshalText(b []byte) error {
+	t, err := time.Parse("2006-01-02", string(b))
+	if err != nil {
+		return err
+	}
+	*d = {{$T}}(t)
+	return nil
+}
+
+// EncodeValues encodes the date in query strings.
+func (d {{$T}}) EncodeValues(key string, v *url.Values) error {
+	v.Set(key, d.String())
+	return nil
+}
+{{else if eq .Kind "Duration"}}
+// {{$T}} is a duration of the duration format, written as in ISO 8601,
+// e.g. "PT1H30M". Years and months, whose lengths vary, are not supported.
+type {{$T}} time.Duration
+
+var durationPattern = regexp.MustCompile("^(-?)P(?:([0-9]+)W)?(?:([0-9]+)D)?(?:T(?:([0-9]+)H)?(?:([0-9]+)M)?(?:([0-9]+(?:[.][0-9]+)?)S)?)?$")
+
+func (d {{$T}}) String() string {
+	v := time.Duration(d)
+	s := "PT"
+	if v < 0 {
+		s, v = "-PT", -v
+	}
+	if h := v / time.Hour; h > 0 {
+		s += strconv.FormatInt(int64(h), 10) + "H"
+		v -= h * time.Hour
+	}
+	if m := v / time.Minute; m > 0 {
+		s += strconv.FormatInt(int64(m), 10) + "M"
+		v -= m * time.Minute
+	}
+	if v > 0 || strings.HasSuffix(s, "T") {
+		s += strconv.FormatFloat(v.Seconds(), 'f', -1, 64) + "S"
+	}
+	return s
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d {{$T}}) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *{{$T}}) UnmarshalText(b []byte) error {
+	s := string(b)
+	m := durationPattern.FindStringSubmatch(s)
+	if m == nil || strings.HasSuffix(s, "P") || strings.HasSuffix(s, "T") {
+		return fmt.Errorf("invalid duration %q", s)
+	}
+	var v float64
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	for i, unit := range units {
+		if m[i+2] == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(m[i+2], 64)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %v", s, err)
+		}
+		v += n * float64(unit)
+	}
+	if m[1] == "-" {
+		v = -v
+	}
+	*d = {{$T}}(v)
+	return nil
+}
+
+// EncodeValues encodes the duration in query strings.
+func (d {{$T}}) EncodeValues(key string, v *url.Values) error {
+	v.Set(key, d.String())
+	return nil
+}
+{{else if eq .Kind "Email"}}
+// {{$T}} is an email address of the email format. Values are validated
+// when unmarshaled, the empty zero value aside.
+type {{$T}} string
+
+// Valid returns true if e is a bare email address.
+func (e {{$T}}) Valid() bool {
+	a, err := mail.ParseAddress(string(e))
+	return err == nil && a.Name == "" && a.Address == string(e)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (e *{{$T}}) UnmarshalText(b []byte) error {
+	if len(b) > 0 && !{{$T}}(b).Valid() {
+		return fmt.Errorf("invalid email address %q", b)
+	}
+	*e = {{$T}}(b)
+	return nil
+}
+{{else if eq .Kind "URI"}}
+// {{$T}} is an absolute URI of the uri format. Values are validated when
+// unmarshaled, the empty zero value aside.
+type {{$T}} string
+
+// URL parses the URI.
+func (u {{$T}}) URL() (*url.URL, error) {
+	return url.Parse(string(u))
+}
+
+// Valid returns true if u is an absolute URI.
+func (u {{$T}}) Valid() bool {
+	p, err := u.URL()
+	return err == nil && p.IsAbs()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (u *{{$T}}) UnmarshalText(b []byte) error {
+	if len(b) > 0 && !{{$T}}(b).Valid() {
+		return fmt.Errorf("invalid URI %q", b)
+	}
+	*u = {{$T}}(b)
+	return nil
+}
+{{else if eq .Kind "UUID"}}
+// {{$T}} is an identifier of the uuid format. Values are validated when
+// unmarshaled, the empty zero value aside.
+type {{$T}} string
+
+var uuidPattern = regexp.MustCompile("^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$")
+
+// Valid returns true if u is a UUID in its canonical form.
+func (u {{$T}}) Valid() bool {
+	return uuidPattern.MatchString(string(u))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (u *{{$T}}) UnmarshalText(b []byte) error {
+	if len(b) > 0 && !{{$T}}(b).Valid() {
+		return fmt.Errorf("invalid UUID %q", b)
+	}
+	*u = {{$T}}(b)
+	return nil
+}
+{{end}}
+{{end}}
 `,
 	"funcs.tmpl": `{{$Name := .Name}}
 {{$Def := .Definition}}
 {{range .Definition.Links}}
   {{if .AcceptsCustomType}}
    type {{paramType $Name .}} {{linkGoType .}}
+
+   {{validation $Name .}}
   {{end}}
 
   {{if (defineCustomType $Def .)}}
-   type {{returnType $Name $Def .}} {{$Def.ReturnedGoType $Name .}}
+   type {{returnType $Name $Def .}} {{returnedGoType $Def $Name .}}
   {{end}}
 
+  {{$Func := printf "%s-%s" $Name .Title | initialCap}}
   {{asComment .Description}}
-  func (s *Service) {{printf "%s-%s" $Name .Title | initialCap}}(ctx context.Context, {{params $Name .}}) ({{values $Name $Def .}}) {
+  func (s *{{service}}) {{$Func}}(ctx context.Context, {{params $Name .}}) ({{values $Name $Def .}}) {
+    ctx = withMethod(ctx, "{{$Func}}")
     {{if ($Def.EmptyResult .)}}
       return s.{{methodCap .Method}}(ctx, nil, fmt.Sprintf("{{.HRef}}", {{args .HRef}}){{requestParams .}})
     {{else}}
@@ -24,6 +256,62 @@ var templates = map[string]string{"field.tmpl": `{{fieldName .Name}} {{.Type}} {
       return {{if ($Def.ReturnsCustomType .)}}&{{end}}{{$Var}}, s.{{methodCap .Method}}(ctx, &{{$Var}}, fmt.Sprintf("{{.HRef}}", {{args .HRef}}){{requestParams .}})
     {{end}}
   }
+
+  {{if .Paginated}}{{$Item := listItemType $Def $Name .}}{{if $Item}}
+    {{$Iter := printf "%s-Iterator" $Func | initialCap}}
+    // {{$Iter}} iterates over the results of {{$Func}}All.
+    type {{$Iter}} struct {
+      // p fetches the pages, the iterator only goes over page when nil.
+      p     *pager
+      page  []{{$Item}}
+      value {{$Item}}
+      err   error
+    }
+
+    // Next advances the iterator to the next item, fetching the next page
+    // when needed. It returns false when the list is exhausted, the context
+    // is cancelled or an error occurred.
+    func (it *{{$Iter}}) Next() bool {
+      for len(it.page) == 0 {
+        if it.p == nil || it.err != nil {
+          return false
+        }
+        it.page = nil
+        ok, err := it.p.fetch(&it.page)
+        if err != nil {
+          it.err = err
+          return false
+        }
+        if !ok {
+          return false
+        }
+      }
+      if it.p != nil {
+        if err := it.p.ctx.Err(); err != nil {
+          it.err = err
+          return false
+        }
+      }
+      it.value, it.page = it.page[0], it.page[1:]
+      return true
+    }
+
+    // Value returns the current item.
+    func (it *{{$Iter}}) Value() {{$Item}} {
+      return it.value
+    }
+
+    // Err returns the error that stopped the iteration, if any.
+    func (it *{{$Iter}}) Err() error {
+      return it.err
+    }
+
+    // {{$Func}}All returns an iterator over every item of {{$Func}},
+    // following Next-Range headers until the list is exhausted.
+    func (s *{{service}}) {{$Func}}All(ctx context.Context, {{params $Name .}}) *{{$Iter}} {
+      return &{{$Iter}}{p: s.pager(withMethod(ctx, "{{$Func}}"), fmt.Sprintf("{{.HRef}}", {{args .HRef}}), {{if .Schema}}o{{else}}nil{{end}}, lr)}
+    }
+  {{end}}{{end}}
 {{end}}
 `,
 	"imports.tmpl": `{{if .}}
@@ -39,48 +327,530 @@ var templates = map[string]string{"field.tmpl": `{{fieldName .Name}} {{.Type}} {
 {{end}}
 
 var _ = time.Second
+`,
+	"interface.tmpl": `// {{service}}Interface lists the methods of {{service}}, for it to be
+// replaced in tests, e.g. by a fake.
+type {{service}}Interface interface {
+{{range .}}{{$Name := .Name}}{{$Def := .Definition}}
+  {{range .Definition.Links}}
+    {{$Func := printf "%s-%s" $Name .Title | initialCap}}
+    {{$Func}}(ctx context.Context, {{params $Name .}}) ({{values $Name $Def .}})
+    {{if .Paginated}}{{if listItemType $Def $Name .}}
+      {{$Func}}All(ctx context.Context, {{params $Name .}}) *{{printf "%s-Iterator" $Func | initialCap}}
+    {{end}}{{end}}
+  {{end}}
+{{end}}
+}
+
+var _ {{service}}Interface = (*{{service}})(nil)
+`,
+	"mock.tmpl": `// Package {{.Package}} serves a fake {{.Name}} API for tests, answering
+// requests with the examples of the schema:
+//
+//     srv := {{.Package}}.NewServer()
+//     defer srv.Close()
+//
+package {{.Package}}
+
+import (
+  "encoding/json"
+  "fmt"
+  "net/http"
+  "net/http/httptest"
+  "regexp"
+)
+
+// NewServer starts and returns a new server serving the API. The caller
+// should call Close when finished, to shut it down.
+func NewServer() *httptest.Server {
+  return httptest.NewServer(Handler())
+}
+
+// Handler returns an http.Handler serving the API. Request bodies are
+// validated against the link schemas, invalid ones being answered by a
+// 422 Unprocessable Entity.
+func Handler() http.Handler {
+  return handler{}
+}
+
+type route struct {
+  method   string
+  pattern  *regexp.Regexp
+  status   int
+  response string
+  schema   *rule
+}
+
+var routes = []*route{
+{{range .Routes}}
+  {
+    method:   {{printf "%q" .Method}},
+    pattern:  regexp.MustCompile({{printf "%q" .Route}}),
+    status:   {{.Status}},
+    response: {{printf "%q" .Response}},
+    {{if .Schema}}schema:   mustRule({{printf "%q" .Schema}}),{{end}}
+  },
+{{end}}
+}
+
+type handler struct{}
+
+// ServeHTTP answers with the route matching the request having the fewest
+// variables, so that static paths take precedence.
+func (handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+  var match *route
+  vars := -1
+  allowed := false
+  for _, rte := range routes {
+    m := rte.pattern.FindStringSubmatch(r.URL.EscapedPath())
+    if m == nil {
+      continue
+    }
+    allowed = true
+    if rte.method != r.Method {
+      continue
+    }
+    if match == nil || len(m)-1 < vars {
+      match, vars = rte, len(m)-1
+    }
+  }
+  switch {
+  case match == nil && allowed:
+    writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", http.StatusText(http.StatusMethodNotAllowed))
+    return
+  case match == nil:
+    writeError(w, http.StatusNotFound, "not_found", http.StatusText(http.StatusNotFound))
+    return
+  }
+  if match.schema != nil {
+    var body interface{}
+    if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+      writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+      return
+    }
+    if err := match.schema.validate(body, "body"); err != nil {
+      writeError(w, http.StatusUnprocessableEntity, "invalid_params", err.Error())
+      return
+    }
+  }
+  if match.response == "" {
+    w.WriteHeader(match.status)
+    return
+  }
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(match.status)
+  fmt.Fprint(w, match.response)
+}
+
+func writeError(w http.ResponseWriter, status int, id, message string) {
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(status)
+  json.NewEncoder(w).Encode(map[string]string{"id": id, "message": message})
+}
+
+// rule is the subset of a JSON schema values are validated against.
+type rule struct {
+  Type       []string
+  Enum       []string
+  Required   []string
+  Properties map[string]*rule
+  Items      *rule
+  Branches   []*rule
+}
+
+func mustRule(s string) *rule {
+  r := new(rule)
+  if err := json.Unmarshal([]byte(s), r); err != nil {
+    panic(err)
+  }
+  return r
+}
+
+// validate returns an error describing the first violation of the rule by v,
+// found at the given path.
+func (r *rule) validate(v interface{}, path string) error {
+  if r == nil {
+    return nil
+  }
+  if len(r.Branches) > 0 {
+    for _, b := range r.Branches {
+      if b.validate(v, path) == nil {
+        return nil
+      }
+    }
+    return fmt.Errorf("%s matches none of the allowed schemas", path)
+  }
+  if len(r.Type) > 0 && !r.hasType(v) {
+    return fmt.Errorf("%s must be of type %v", path, r.Type)
+  }
+  if s, ok := v.(string); ok && len(r.Enum) > 0 {
+    found := false
+    for _, e := range r.Enum {
+      found = found || e == s
+    }
+    if !found {
+      return fmt.Errorf("%s must be one of %v", path, r.Enum)
+    }
+  }
+  switch t := v.(type) {
+  case map[string]interface{}:
+    for _, name := range r.Required {
+      if _, ok := t[name]; !ok {
+        return fmt.Errorf("%s.%s is required", path, name)
+      }
+    }
+    for name, p := range r.Properties {
+      if pv, ok := t[name]; ok {
+        if err := p.validate(pv, path+"."+name); err != nil {
+          return err
+        }
+      }
+    }
+  case []interface{}:
+    for i, item := range t {
+      if err := r.Items.validate(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+        return err
+      }
+    }
+  }
+  return nil
+}
+
+func (r *rule) hasType(v interface{}) bool {
+  for _, t := range r.Type {
+    switch v := v.(type) {
+    case nil:
+      if t == "null" {
+        return true
+      }
+    case bool:
+      if t == "boolean" {
+        return true
+      }
+    case float64:
+      if t == "number" || t == "integer" && v == float64(int64(v)) {
+        return true
+      }
+    case string:
+      if t == "string" {
+        return true
+      }
+    case []interface{}:
+      if t == "array" {
+        return true
+      }
+    case map[string]interface{}:
+      if t == "object" {
+        return true
+      }
+    }
+  }
+  return false
+}
+`,
+	"object.tmpl": `{{$Name := .Name}}
+{{asComment .Definition.Description}}
+type {{$Name}} {{.Type}}
 `,
 	"package.tmpl": `// Generated service client for {{.}} API.
 //
 // To be able to interact with this API, you have to
 // create a new service:
 //
-//     s := {{.}}.NewService(nil)
+//     s := {{.}}.New{{service}}(nil)
 //
-// The Service struct has all the methods you need
+// The {{service}} struct has all the methods you need
 // to interact with {{.}} API.
 //
 package {{.}}
+`,
+	"server.tmpl": `// {{service}}Handler is implemented by servers of the API, with a method per
+// link sharing the signature of the {{service}} one.
+type {{service}}Handler interface {
+{{range .}}{{$Name := .Name}}{{$Def := .Definition}}
+  {{range .Definition.Links}}
+    {{printf "%s-%s" $Name .Title | initialCap}}(ctx context.Context, {{params $Name .}}) ({{values $Name $Def .}})
+  {{end}}
+{{end}}
+}
+
+// New{{service}}Router returns an http.Handler serving the API with h. The
+// href variables are parsed from the path, bodies, or query strings of GET
+// requests, are decoded into the link parameters, and the Range header into
+// a *ListRange. Errors returned as *{{runtimeType "Error"}} are sent with their status code.
+func New{{service}}Router(h {{service}}Handler) http.Handler {
+  rt := new(router)
+{{range .}}{{$Name := .Name}}{{$Def := .Definition}}
+  {{range .Definition.Links}}
+    {{$Method := methodCap .Method}}
+    rt.handle({{printf "%q" .Method}}, {{route .HRef | printf "%q"}}, func(w http.ResponseWriter, r *http.Request, vars []string) error {
+      {{range $i, $p := paramList $Name .}}
+        {{if eq $p.Name "lr"}}
+          lr := parseRange(r)
+        {{else if eq $p.Name "o"}}
+          var o {{$p.Type}}
+          if err := {{if eq $Method "Get"}}decodeQuery{{else}}decodeBody{{end}}(r, &o); err != nil {
+            return err
+          }
+        {{else}}
+          var {{$p.Name}} {{$p.Type}}
+          if err := parseVar(vars[{{$i}}], &{{$p.Name}}); err != nil {
+            return err
+          }
+        {{end}}
+      {{end}}
+      {{if ($Def.EmptyResult .)}}
+        if err := h.{{printf "%s-%s" $Name .Title | initialCap}}(r.Context(), {{paramNames $Name .}}); err != nil {
+          return err
+        }
+        w.WriteHeader({{if eq .Rel "create"}}http.StatusCreated{{else}}http.StatusOK{{end}})
+        return nil
+      {{else}}
+        result, err := h.{{printf "%s-%s" $Name .Title | initialCap}}(r.Context(), {{paramNames $Name .}})
+        if err != nil {
+          return err
+        }
+        return writeJSON(w, {{if eq .Rel "create"}}http.StatusCreated{{else}}http.StatusOK{{end}}, result)
+      {{end}}
+    })
+  {{end}}
+{{end}}
+  return rt
+}
+
+// router dispatches requests to the route matching their method and path.
+type router struct {
+  routes []*route
+}
+
+type route struct {
+  method  string
+  pattern *regexp.Regexp
+  serve   func(w http.ResponseWriter, r *http.Request, vars []string) error
+}
+
+func (rt *router) handle(method, pattern string, serve func(w http.ResponseWriter, r *http.Request, vars []string) error) {
+  rt.routes = append(rt.routes, &route{
+    method:  strings.ToUpper(method),
+    pattern: regexp.MustCompile(pattern),
+    serve:   serve,
+  })
+}
+
+// ServeHTTP serves the request with the matching route having the fewest
+// variables, so that static paths take precedence.
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+  var match *route
+  var vars []string
+  allowed := false
+  for _, rte := range rt.routes {
+    m := rte.pattern.FindStringSubmatch(r.URL.EscapedPath())
+    if m == nil {
+      continue
+    }
+    allowed = true
+    if rte.method != r.Method {
+      continue
+    }
+    if match == nil || len(m)-1 < len(vars) {
+      match, vars = rte, m[1:]
+    }
+  }
+  if match == nil {
+    if allowed {
+      writeError(w, &{{runtimeType "Error"}}{StatusCode: http.StatusMethodNotAllowed, ID: "method_not_allowed", Message: http.StatusText(http.StatusMethodNotAllowed)})
+    } else {
+      writeError(w, &{{runtimeType "Error"}}{StatusCode: http.StatusNotFound, ID: "not_found", Message: http.StatusText(http.StatusNotFound)})
+    }
+    return
+  }
+  for i, v := range vars {
+    if u, err := url.PathUnescape(v); err == nil {
+      vars[i] = u
+    }
+  }
+  if err := match.serve(w, r, vars); err != nil {
+    writeError(w, err)
+  }
+}
+
+// badRequest returns an *{{runtimeType "Error"}} reporting an invalid request.
+func badRequest(err error) error {
+  return &{{runtimeType "Error"}}{StatusCode: http.StatusBadRequest, ID: "bad_request", Message: err.Error()}
+}
+
+// parseVar parses a href variable into v, as JSON or as a JSON string.
+func parseVar(s string, v interface{}) error {
+  if p, ok := v.(*string); ok {
+    *p = s
+    return nil
+  }
+  if json.Unmarshal([]byte(s), v) == nil {
+    return nil
+  }
+  q, err := json.Marshal(s)
+  if err != nil {
+    return badRequest(err)
+  }
+  if err := json.Unmarshal(q, v); err != nil {
+    return badRequest(err)
+  }
+  return nil
+}
+
+// decodeBody decodes the JSON request body into v, if any.
+func decodeBody(r *http.Request, v interface{}) error {
+  err := json.NewDecoder(r.Body).Decode(v)
+  if err != nil && err != io.EOF {
+    return badRequest(err)
+  }
+  return nil
+}
+
+// decodeQuery decodes the query string into v, each parameter being decoded
+// as JSON, or as JSON strings if that fails.
+func decodeQuery(r *http.Request, v interface{}) error {
+  for k, vs := range r.URL.Query() {
+    if decodeParam(k, vs, v, false) == nil {
+      continue
+    }
+    if err := decodeParam(k, vs, v, true); err != nil {
+      return badRequest(err)
+    }
+  }
+  return nil
+}
+
+func decodeParam(k string, vs []string, v interface{}, quoted bool) error {
+  values := make([]json.RawMessage, len(vs))
+  for i, s := range vs {
+    if quoted || !json.Valid([]byte(s)) {
+      values[i], _ = json.Marshal(s)
+    } else {
+      values[i] = json.RawMessage(s)
+    }
+  }
+  var value interface{} = values
+  if len(values) == 1 {
+    value = values[0]
+  }
+  data, err := json.Marshal(map[string]interface{}{k: value})
+  if err != nil {
+    return err
+  }
+  return json.Unmarshal(data, v)
+}
+
+// parseRange parses the Range header set by ListRange.SetHeader, it returns
+// nil if there is none.
+func parseRange(r *http.Request) *ListRange {
+  v := r.Header.Get("Range")
+  if v == "" {
+    return nil
+  }
+  lr := new(ListRange)
+  parts := strings.SplitN(v, ";", 2)
+  spec := strings.TrimSpace(parts[0])
+  if i := strings.Index(spec, " "); i >= 0 {
+    lr.Field, spec = spec[:i], spec[i+1:]
+  }
+  ids := strings.SplitN(spec, "..", 2)
+  lr.FirstID = ids[0]
+  if len(ids) == 2 {
+    lr.LastID = ids[1]
+  }
+  if len(parts) == 2 {
+    for _, p := range strings.Split(parts[1], ",") {
+      kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+      if len(kv) != 2 {
+        continue
+      }
+      switch kv[0] {
+      case "max":
+        lr.Max, _ = strconv.Atoi(kv[1])
+      case "order":
+        lr.Descending = kv[1] == "desc"
+      }
+    }
+  }
+  return lr
+}
+
+// writeJSON sends v encoded as JSON with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) error {
+  data, err := json.Marshal(v)
+  if err != nil {
+    return err
+  }
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(status)
+  w.Write(data)
+  return nil
+}
+
+// writeError sends err following the error convention, as a 500 Internal
+// Server Error unless it is an *{{runtimeType "Error"}}.
+func writeError(w http.ResponseWriter, err error) {
+  var e *{{runtimeType "Error"}}
+  if !errors.As(err, &e) || e.StatusCode == 0 {
+    e = &{{runtimeType "Error"}}{StatusCode: http.StatusInternalServerError, ID: "internal_server_error", Message: http.StatusText(http.StatusInternalServerError)}
+  }
+  body := map[string]string{"id": e.ID, "message": e.Message}
+  if e.URL != "" {
+    body["url"] = e.URL
+  }
+  writeJSON(w, e.StatusCode, body)
+}
 `,
 	"service.tmpl": `const (
 	Version          = "{{.Version}}"
-	DefaultUserAgent = "{{.Name}}/" + Version + " (" + runtime.GOOS + "; " + runtime.GOARCH + ")"
+	DefaultUserAgent = "{{.UserAgent}}/" + Version + " (" + runtime.GOOS + "; " + runtime.GOARCH + ")"
 	DefaultURL       = "{{.URL}}"
 )
 
-// Service represents your API.
-type Service struct {
+// {{service}} represents your API.
+type {{service}} struct {
 	client *http.Client
 	URL string
+	// Retry decides whether failed requests are retried, they are not
+	// when nil.
+	Retry RetryPolicy
+	// Limiter throttles requests, they are not when nil.
+	Limiter *Limiter
+	// ValidateRequests checks the request bodies and query parameters
+	// implementing Validator before sending them.
+	ValidateRequests bool
+
+	middleware []Middleware
+	mu         sync.Mutex
+	rateLimit  RateLimit
 }
 
-// NewService creates a Service using the given, if none is provided
+// New{{service}} creates a {{service}} using the given, if none is provided
 // it uses http.DefaultClient.
-func NewService(c *http.Client) *Service {
+func New{{service}}(c *http.Client) *{{service}} {
 	if c == nil {
 		c = http.DefaultClient
 	}
-	return &Service{
+	return &{{service}}{
 		client: c,
 		URL: DefaultURL,
 	}
 }
 
 // NewRequest generates an HTTP request, but does not perform the request.
-func (s *Service) NewRequest(ctx context.Context, method, path string, body interface{}, q interface{}) (*http.Request, error) {
+func (s *{{service}}) NewRequest(ctx context.Context, method, path string, body interface{}, q interface{}) (*http.Request, error) {
 	var ctype string
 	var rbody io.Reader
 
+	if s.ValidateRequests {
+		for _, v := range []interface{}{body, q} {
+			if err := validate(v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	switch t := body.(type) {
 	case nil:
 	case string:
@@ -134,7 +904,7 @@ func (s *Service) NewRequest(ctx context.Context, method, path string, body inte
 }
 
 // Do sends a request and decodes the response into v.
-func (s *Service) Do(ctx context.Context, v interface{}, method, path string, body interface{}, q interface{}, lr *ListRange) error {
+func (s *{{service}}) Do(ctx context.Context, v interface{}, method, path string, body interface{}, q interface{}, lr *ListRange) error {
 	req, err := s.NewRequest(ctx, method, path, body, q)
 	if err != nil {
 		return err
@@ -142,9 +912,16 @@ func (s *Service) Do(ctx context.Context, v interface{}, method, path string, bo
 	if lr != nil {
 		lr.SetHeader(req)
 	}
-	resp, err := s.client.Do(req)
+	_, err = s.send(req, v)
+	return err
+}
+
+// send performs the request and decodes the response into v. The returned
+// response body is already closed.
+func (s *{{service}}) send(req *http.Request, v interface{}) (*http.Response, error) {
+	resp, err := s.do(req)
 	if err != nil {
-		return err
+		return resp, err
 	}
 	defer resp.Body.Close()
 	switch t := v.(type) {
@@ -154,31 +931,433 @@ func (s *Service) Do(ctx context.Context, v interface{}, method, path string, bo
 	default:
 		err = json.NewDecoder(resp.Body).Decode(v)
 	}
-	return err
+	return resp, err
+}
+
+// do performs the request, retrying it according to the retry policy. The
+// body of unsuccessful responses is already closed.
+func (s *{{service}}) do(req *http.Request) (*http.Response, error) {
+	send := s.chain(methodName(req.Context()))
+	for attempt := 1; ; attempt++ {
+		if s.Limiter != nil {
+			if err := s.Limiter.Wait(req.Context()); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := send(req)
+		if err == nil {
+			s.observe(resp)
+			if err = checkResponse(resp); err == nil {
+				return resp, nil
+			}
+			resp.Body.Close()
+		}
+		if s.Retry == nil || !rewindable(req) {
+			return resp, err
+		}
+		wait, ok := s.Retry.Retry(req, resp, err, attempt)
+		if !ok {
+			return resp, err
+		}
+		if err := sleep(req.Context(), wait); err != nil {
+			return resp, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return resp, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+// Doer sends a request and returns its response.
+type Doer func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps every attempt at sending a request, calling next to
+// send it. It is given the name of the generated method sending the
+// request, e.g. "AppInfo", or an empty string for requests sent with Do.
+type Middleware func(method string, req *http.Request, next Doer) (*http.Response, error)
+
+// Use appends middleware to the chain wrapping requests, the first one
+// being the outermost. It must not be called concurrently with requests.
+func (s *{{service}}) Use(middleware ...Middleware) {
+	s.middleware = append(s.middleware, middleware...)
+}
+
+// chain returns the Doer sending the requests of the named method through
+// the middleware.
+func (s *{{service}}) chain(method string) Doer {
+	send := Doer(s.client.Do)
+	for i := len(s.middleware) - 1; i >= 0; i-- {
+		m, next := s.middleware[i], send
+		send = func(req *http.Request) (*http.Response, error) {
+			return m(method, req, next)
+		}
+	}
+	return send
+}
+
+type methodKey struct{}
+
+// withMethod returns a context carrying the name of the generated method
+// sending requests.
+func withMethod(ctx context.Context, method string) context.Context {
+	return context.WithValue(ctx, methodKey{}, method)
+}
+
+func methodName(ctx context.Context) string {
+	method, _ := ctx.Value(methodKey{}).(string)
+	return method
+}
+
+// RateLimit describes the rate limit last reported by the API.
+type RateLimit struct {
+	// Remaining is the number of requests left, from the
+	// RateLimit-Remaining header.
+	Remaining int
+	// Reset is the delay before the budget is replenished, from the
+	// RateLimit-Reset header, or zero if not reported.
+	Reset time.Duration
+	// Time is when the rate limit was observed, zero if never.
+	Time time.Time
+}
+
+// RateLimit returns the rate limit last reported by the API.
+func (s *{{service}}) RateLimit() RateLimit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rateLimit
+}
+
+// observe records the rate limit reported by the response.
+func (s *{{service}}) observe(resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	rl := RateLimit{Remaining: remaining, Time: time.Now()}
+	if secs, err := strconv.Atoi(resp.Header.Get("RateLimit-Reset")); err == nil {
+		rl.Reset = time.Duration(secs) * time.Second
+	}
+	s.mu.Lock()
+	s.rateLimit = rl
+	s.mu.Unlock()
+	if s.Limiter != nil {
+		s.Limiter.Update(remaining)
+	}
+}
+
+// Limiter is a token bucket throttling requests. It is refilled at a
+// steady rate, and its level follows the RateLimit-Remaining header of the
+// responses.
+type Limiter struct {
+	rate   float64
+	burst  float64
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter creates a Limiter allowing bursts of up to burst requests,
+// replenished at rate requests per second. For instance, an API allowing
+// 4500 requests per hour is matched by NewLimiter(4500.0/3600, 4500).
+func NewLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+// Wait blocks until a request can be sent, or the context is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		l.refill()
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Second
+		if l.rate > 0 {
+			wait = time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		}
+		l.mu.Unlock()
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// Update sets the number of requests left to the one reported by the API.
+func (l *Limiter) Update(remaining int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	l.tokens = float64(remaining)
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+func (l *Limiter) refill() {
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+}
+
+// rewindable returns true if the request body can be sent again.
+func rewindable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// sleep waits for d, or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// RetryPolicy decides whether a failed request is retried.
+type RetryPolicy interface {
+	// Retry is given the request, its response if any, the error of the
+	// given attempt, starting at 1, and returns how long to wait before
+	// the next one, or false to give up.
+	Retry(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool)
+}
+
+// RetryPolicyFunc is an adapter to use ordinary functions as RetryPolicy.
+type RetryPolicyFunc func(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool)
+
+// Retry calls f(req, resp, err, attempt).
+func (f RetryPolicyFunc) Retry(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	return f(req, resp, err, attempt)
+}
+
+// Backoff retries requests failing with a connection error, a 429 or a 5xx
+// gateway status, waiting exponentially longer between attempts. The
+// Retry-After header is honored, as well as RateLimit-Remaining: once the
+// budget is exhausted, it waits for RateLimit-Reset seconds, or MaxDelay.
+type Backoff struct {
+	// MaxAttempts is the maximum number of attempts, 3 by default.
+	MaxAttempts int
+	// Delay is the wait before the first retry, 500ms by default. It
+	// doubles with each attempt, with a random jitter.
+	Delay time.Duration
+	// MaxDelay caps the wait between attempts, 30s by default.
+	MaxDelay time.Duration
+	// Methods lists the retried methods, the idempotent ones by default.
+	Methods []string
+}
+
+// Retry implements RetryPolicy.
+func (b *Backoff) Retry(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	max, delay, maxDelay := b.MaxAttempts, b.Delay, b.MaxDelay
+	if max == 0 {
+		max = 3
+	}
+	if delay == 0 {
+		delay = 500 * time.Millisecond
+	}
+	if maxDelay == 0 {
+		maxDelay = 30 * time.Second
+	}
+	if attempt >= max || req.Context().Err() != nil || !b.retried(req.Method) {
+		return 0, false
+	}
+	if resp != nil {
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		default:
+			return 0, false
+		}
+		if d, ok := retryAfter(resp); ok {
+			return d, true
+		}
+		if resp.Header.Get("RateLimit-Remaining") == "0" {
+			if secs, err := strconv.Atoi(resp.Header.Get("RateLimit-Reset")); err == nil && secs >= 0 {
+				return time.Duration(secs) * time.Second, true
+			}
+			return maxDelay, true
+		}
+	}
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1)), true
+}
+
+func (b *Backoff) retried(method string) bool {
+	methods := b.Methods
+	if methods == nil {
+		methods = []string{"GET", "HEAD", "OPTIONS", "PUT", "DELETE"}
+	}
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryAfter returns the delay requested by the Retry-After header.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// pager fetches the successive pages of a list, following the Next-Range
+// header of partial responses.
+type pager struct {
+	s    *{{service}}
+	ctx  context.Context
+	path string
+	q    interface{}
+	lr   *ListRange
+	next string
+	done bool
+}
+
+func (s *{{service}}) pager(ctx context.Context, path string, q interface{}, lr *ListRange) *pager {
+	return &pager{s: s, ctx: ctx, path: path, q: q, lr: lr}
+}
+
+// fetch decodes the next page into v. It returns false once the list is
+// exhausted.
+func (p *pager) fetch(v interface{}) (bool, error) {
+	if p.done {
+		return false, nil
+	}
+	if err := p.ctx.Err(); err != nil {
+		return false, err
+	}
+	req, err := p.s.NewRequest(p.ctx, "GET", p.path, nil, p.q)
+	if err != nil {
+		return false, err
+	}
+	if p.next != "" {
+		req.Header.Set("Range", p.next)
+	} else if p.lr != nil {
+		p.lr.SetHeader(req)
+	}
+	resp, err := p.s.send(req, v)
+	if err != nil {
+		return false, err
+	}
+	p.next = resp.Header.Get("Next-Range")
+	p.done = resp.StatusCode != http.StatusPartialContent || p.next == ""
+	return true, nil
+}
+
+// {{runtimeType "Error"}} represents an error returned by the API.
+type {{runtimeType "Error"}} struct {
+	// ID is the machine readable error identifier, e.g. "not_found".
+	ID string
+	// Message is the human readable error description.
+	Message string
+	// URL references documentation about the error.
+	URL string
+
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// RequestID is the Request-Id header of the response.
+	RequestID string
+	// Body is the raw response body.
+	Body []byte
+}
+
+func (e *{{runtimeType "Error"}}) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if e.ID != "" {
+		return fmt.Sprintf("%s (%d %s)", msg, e.StatusCode, e.ID)
+	}
+	return fmt.Sprintf("%s (%d)", msg, e.StatusCode)
+}
+
+// checkResponse returns an *{{runtimeType "Error"}} if the response status isn't successful.
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	e := &{{runtimeType "Error"}}{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("Request-Id"),
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	e.Body = body
+	// The body isn't guaranteed to follow the error convention, the raw
+	// body is kept around when it doesn't.
+	var msg struct {
+		ID      string
+		Message string
+		URL     string
+	}
+	if json.Unmarshal(body, &msg) == nil {
+		e.ID, e.Message, e.URL = msg.ID, msg.Message, msg.URL
+	}
+	return e
+}
+
+// decodeStrict decodes data into v, rejecting unknown object fields, and
+// returns whether it succeeded.
+func decodeStrict(data []byte, v interface{}) bool {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	return d.Decode(v) == nil
 }
 
 // Get sends a GET request and decodes the response into v.
-func (s *Service) Get(ctx context.Context, v interface{}, path string, query interface{}, lr *ListRange) error {
+func (s *{{service}}) Get(ctx context.Context, v interface{}, path string, query interface{}, lr *ListRange) error {
 	return s.Do(ctx, v, "GET", path, nil, query, lr)
 }
 
 // Patch sends a Path request and decodes the response into v.
-func (s *Service) Patch(ctx context.Context, v interface{}, path string, body interface{}) error {
+func (s *{{service}}) Patch(ctx context.Context, v interface{}, path string, body interface{}) error {
 	return s.Do(ctx, v, "PATCH", path, body, nil, nil)
 }
 
 // Post sends a POST request and decodes the response into v.
-func (s *Service) Post(ctx context.Context, v interface{}, path string, body interface{}) error {
+func (s *{{service}}) Post(ctx context.Context, v interface{}, path string, body interface{}) error {
 	return s.Do(ctx, v, "POST", path, body, nil, nil)
 }
 
 // Put sends a PUT request and decodes the response into v.
-func (s *Service) Put(ctx context.Context, v interface{}, path string, body interface{}) error {
+func (s *{{service}}) Put(ctx context.Context, v interface{}, path string, body interface{}) error {
 	return s.Do(ctx, v, "PUT", path, body, nil, nil)
 }
 
 // Delete sends a DELETE request.
-func (s *Service) Delete(ctx context.Context, v interface{}, path string) error {
+func (s *{{service}}) Delete(ctx context.Context, v interface{}, path string) error {
 	return s.Do(ctx, v, "DELETE", path, nil, nil, nil)
 }
 
@@ -214,6 +1393,86 @@ func (lr *ListRange) SetHeader(req *http.Request) {
 	return
 }
 
+// Validator is implemented by the request types, checking the constraints
+// of the schema.
+type Validator interface {
+	Validate() error
+}
+
+// validate validates v if it implements Validator and is not a nil
+// pointer.
+func validate(v interface{}) error {
+	val, ok := v.(Validator)
+	if !ok {
+		return nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil
+	}
+	return val.Validate()
+}
+
+// ValidationError lists the fields of a request breaking the constraints
+// of the schema.
+type ValidationError struct {
+	Fields []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return "invalid request: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors of the fields.
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Fields))
+	for i, f := range e.Fields {
+		errs[i] = f
+	}
+	return errs
+}
+
+// FieldError is a constraint broken by a field, named by its path, e.g.
+// "region.name" or "tags[0]".
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// validation collects the errors of the fields of a request.
+type validation struct {
+	fields []*FieldError
+}
+
+func (v *validation) add(field, message string) {
+	v.fields = append(v.fields, &FieldError{Field: field, Message: message})
+}
+
+func (v *validation) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: v.fields}
+}
+
+var patterns sync.Map
+
+// matches returns true if s matches the pattern, compiled once.
+func matches(pattern, s string) bool {
+	re, ok := patterns.Load(pattern)
+	if !ok {
+		re, _ = patterns.LoadOrStore(pattern, regexp.MustCompile(pattern))
+	}
+	return re.(*regexp.Regexp).MatchString(s)
+}
+
 // Bool allocates a new int value returns a pointer to it.
 func Bool(v bool) *bool {
 	p := new(bool)
@@ -228,6 +1487,27 @@ func Int(v int) *int {
 	return p
 }
 
+// Int32 allocates a new int32 value returns a pointer to it.
+func Int32(v int32) *int32 {
+	p := new(int32)
+	*p = v
+	return p
+}
+
+// Int64 allocates a new int64 value returns a pointer to it.
+func Int64(v int64) *int64 {
+	p := new(int64)
+	*p = v
+	return p
+}
+
+// Uint64 allocates a new uint64 value returns a pointer to it.
+func Uint64(v uint64) *uint64 {
+	p := new(uint64)
+	*p = v
+	return p
+}
+
 // Float64 allocates a new float64 value returns a pointer to it.
 func Float64(v float64) *float64 {
 	p := new(float64)
@@ -244,6 +1524,87 @@ func String(v string) *string {
 `,
 	"struct.tmpl": `{{asComment .Definition.Description}}
 type {{initialCap .Name}} {{goType .Definition}}
+`,
+	"union.tmpl": `{{$Name := .Name}}
+{{asComment .Definition.Description}}
+type {{$Name}} struct {
+  {{range .Branches}}
+  {{.Field}} {{.Type}}
+  {{end}}
+}
+
+// UnmarshalJSON sets the field of the branch matching data.
+func (u *{{$Name}}) UnmarshalJSON(data []byte) error {
+  *u = {{$Name}}{}
+  if string(data) == "null" {
+    return nil
+  }
+  {{if .Definition.Discriminator}}
+  var fields map[string]json.RawMessage
+  if err := json.Unmarshal(data, &fields); err != nil {
+    return err
+  }
+  var kind string
+  if err := json.Unmarshal(fields[{{printf "%q" .Definition.Discriminator}}], &kind); err != nil {
+    return fmt.Errorf("{{$Name}}: missing {{.Definition.Discriminator}}: %v", err)
+  }
+  switch kind {
+  {{range .Branches}}{{if .Values}}
+  case {{range $i, $v := .Values}}{{if $i}}, {{end}}{{printf "%q" $v}}{{end}}:
+    return json.Unmarshal(data, &u.{{.Field}})
+  {{end}}{{end}}
+  }
+  return fmt.Errorf("{{$Name}}: unknown {{.Definition.Discriminator}} %q", kind)
+  {{else}}
+  {{range .Branches}}
+  if decodeStrict(data, &u.{{.Field}}) {
+    return nil
+  }
+  u.{{.Field}} = nil
+  {{end}}
+  // Fields unknown to every branch, such as ones added by the API since,
+  // leave the first branch accepting the others.
+  {{range .Branches}}
+  if json.Unmarshal(data, &u.{{.Field}}) == nil {
+    return nil
+  }
+  u.{{.Field}} = nil
+  {{end}}
+  return fmt.Errorf("{{$Name}}: %s matches none of the branches", data)
+  {{end}}
+}
+
+// MarshalJSON returns the JSON encoding of the branch that is set.
+func (u {{$Name}}) MarshalJSON() ([]byte, error) {
+  switch {
+  {{range .Branches}}
+  case u.{{.Field}} != nil:
+    return json.Marshal(u.{{.Field}})
+  {{end}}
+  }
+  return []byte("null"), nil
+}
+
+// String returns the value of the branch that is set.
+func (u {{$Name}}) String() string {
+  switch {
+  {{range .Branches}}
+  case u.{{.Field}} != nil:
+    return fmt.Sprint({{if .Pointer}}*{{end}}u.{{.Field}})
+  {{end}}
+  }
+  return ""
+}
+`,
+	"validate.tmpl": `// Validate checks the constraints of the schema, returning a
+// *ValidationError listing the fields breaking them.
+func (o {{.Name}}) Validate() error {
+	var v validation
+	{{range .Checks}}
+	{{.}}
+	{{end}}
+	return v.err()
+}
 `,
 }
 
@@ -0,0 +1,275 @@
+package schematic
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// primitiveTypes lists the primitive types of JSON Schema, along with the
+// "any" type of draft 3 which the generator accepts.
+var primitiveTypes = map[string]bool{
+	"any":     true,
+	"array":   true,
+	"boolean": true,
+	"integer": true,
+	"null":    true,
+	"number":  true,
+	"object":  true,
+	"string":  true,
+}
+
+// Validate checks the schema, before it is resolved, for the problems that
+// would prevent generating code from it: unresolved references, unknown
+// types, links without href or sharing the same title, and href variables
+// that don't point at definitions. It also checks the constraints the JSON
+// Hyper-Schema meta-schema puts on keywords, those decoding the document
+// into a Schema doesn't already enforce. Documents loaded through external
+// references are checked too, their problems pointing inside them. It
+// returns every problem found, in document order.
+func (s *Schema) Validate() []*SchemaError {
+	v := &validator{walked: map[*Schema]bool{s: true}}
+	v.document(s, fragment)
+	for len(v.pending) > 0 {
+		d := v.pending[0]
+		v.pending = v.pending[1:]
+		v.document(d, d.location+fragment)
+	}
+	return v.problems
+}
+
+type validator struct {
+	// doc is the document being checked, which references are relative to.
+	doc      *Schema
+	walked   map[*Schema]bool
+	pending  []*Schema
+	problems []*SchemaError
+}
+
+func (v *validator) report(pointer string, format string, a ...interface{}) {
+	v.problems = append(v.problems, schemaErrorf(pointer, format, a...).(*SchemaError))
+}
+
+func (v *validator) document(d *Schema, pointer string) {
+	v.doc = d
+	v.schema(d, pointer)
+}
+
+// reference reports the reference if it can't be resolved, and queues the
+// document it leads to for checking.
+func (v *validator) reference(ref Reference, pointer string) bool {
+	_, d, err := ref.resolve(v.doc)
+	if d != nil && !v.walked[d] {
+		v.walked[d] = true
+		v.pending = append(v.pending, d)
+	}
+	if err != nil {
+		var se *SchemaError
+		if errors.As(err, &se) {
+			err = se.Err
+		}
+		v.report(pointer, "unresolved reference %s: %v", ref, err)
+		return false
+	}
+	return true
+}
+
+func (v *validator) schema(s *Schema, pointer string) {
+	if s == nil {
+		return
+	}
+	if s.Ref != nil {
+		v.reference(*s.Ref, pointerTo(pointer, "$ref"))
+	}
+	v.typ(s, pointer)
+	v.keywords(s, pointer)
+	for _, name := range sortedKeys(s.Definitions) {
+		v.schema(s.Definitions[name], pointerTo(pointer, "definitions", name))
+	}
+	for _, name := range sortedKeys(s.Properties) {
+		v.schema(s.Properties[name], pointerTo(pointer, "properties", name))
+	}
+	for _, name := range sortedKeys(s.PatternProperties) {
+		v.schema(s.PatternProperties[name], pointerTo(pointer, "patternProperties", name))
+	}
+	v.schema(s.Items, pointerTo(pointer, "items"))
+	for i, b := range s.AllOf {
+		v.schema(b, pointerTo(pointer, "allOf", strconv.Itoa(i)))
+	}
+	for i, b := range s.AnyOf {
+		v.schema(b, pointerTo(pointer, "anyOf", strconv.Itoa(i)))
+	}
+	for i, b := range s.OneOf {
+		v.schema(b, pointerTo(pointer, "oneOf", strconv.Itoa(i)))
+	}
+	v.schema(s.Not, pointerTo(pointer, "not"))
+
+	titles := make(map[string]int)
+	for i, l := range s.Links {
+		lp := pointerTo(pointer, "links", strconv.Itoa(i))
+		title := strings.ToLower(l.Title)
+		if j, ok := titles[title]; ok {
+			v.report(lp, "duplicate link title %q, also used by links/%d", l.Title, j)
+		} else {
+			titles[title] = i
+		}
+		v.link(l, lp)
+	}
+}
+
+// typ reports type values that aren't JSON Schema primitive types.
+func (v *validator) typ(s *Schema, pointer string) {
+	switch t := s.Type.(type) {
+	case nil:
+	case string:
+		if !primitiveTypes[t] {
+			v.report(pointerTo(pointer, "type"), "unknown type %q", t)
+		}
+	case []interface{}:
+		for i, e := range t {
+			if name, ok := e.(string); !ok || !primitiveTypes[name] {
+				v.report(pointerTo(pointer, "type", strconv.Itoa(i)), "unknown type %v", e)
+			}
+		}
+	default:
+		v.report(pointerTo(pointer, "type"), "invalid type %v", t)
+	}
+}
+
+// keywords reports the keyword values the meta-schema rules out.
+func (v *validator) keywords(s *Schema, pointer string) {
+	if s.MultipleOf < 0 {
+		v.report(pointerTo(pointer, "multipleOf"), "multipleOf must be greater than 0")
+	}
+	if s.ExclusiveMaximum && s.Maximum == nil {
+		v.report(pointerTo(pointer, "exclusiveMaximum"), "exclusiveMaximum requires maximum")
+	}
+	if s.ExclusiveMinimum && s.Minimum == nil {
+		v.report(pointerTo(pointer, "exclusiveMinimum"), "exclusiveMinimum requires minimum")
+	}
+	for _, c := range []struct {
+		keyword string
+		value   int
+	}{
+		{"maxLength", s.MaxLength},
+		{"minLength", s.MinLength},
+		{"maxProperties", s.MaxProperties},
+		{"minProperties", s.MinProperties},
+		{"maxItems", s.MaxItems},
+		{"minItems", s.MinItems},
+	} {
+		if c.value < 0 {
+			v.report(pointerTo(pointer, c.keyword), "%s must not be negative", c.keyword)
+		}
+	}
+	v.pattern(s.Pattern, pointerTo(pointer, "pattern"))
+	for _, name := range sortedKeys(s.PatternProperties) {
+		v.pattern(name, pointerTo(pointer, "patternProperties", name))
+	}
+	v.set("required", s.Required, pointer)
+	v.set("enum", s.Enum, pointer)
+	if types, ok := s.Type.([]interface{}); ok {
+		var names []string
+		for _, t := range types {
+			if name, ok := t.(string); ok {
+				names = append(names, name)
+			}
+		}
+		if len(names) == len(types) {
+			v.set("type", names, pointer)
+		}
+	}
+	for _, c := range []struct {
+		keyword  string
+		branches []*Schema
+	}{
+		{"allOf", s.AllOf},
+		{"anyOf", s.AnyOf},
+		{"oneOf", s.OneOf},
+	} {
+		if c.branches != nil && len(c.branches) == 0 {
+			v.report(pointerTo(pointer, c.keyword), "%s must not be empty", c.keyword)
+		}
+	}
+	v.schemaOrBool("additionalProperties", s.AdditionalProperties, pointer)
+	v.schemaOrBool("additionalItems", s.AdditionalItems, pointer)
+	var names []string
+	for name := range s.Dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		switch d := s.Dependencies[name].(type) {
+		case map[string]interface{}:
+		case []interface{}:
+			for i, e := range d {
+				if _, ok := e.(string); !ok {
+					v.report(pointerTo(pointer, "dependencies", name, strconv.Itoa(i)), "invalid dependency %v", e)
+				}
+			}
+		default:
+			v.report(pointerTo(pointer, "dependencies", name), "invalid dependency %v", d)
+		}
+	}
+}
+
+// pattern reports a pattern that doesn't compile.
+func (v *validator) pattern(expr, pointer string) {
+	if _, err := regexp.Compile(expr); err != nil {
+		v.report(pointer, "invalid pattern: %v", err)
+	}
+}
+
+// set reports an empty keyword value, or one listing an item twice.
+func (v *validator) set(keyword string, values []string, pointer string) {
+	if values != nil && len(values) == 0 {
+		v.report(pointerTo(pointer, keyword), "%s must not be empty", keyword)
+	}
+	seen := make(map[string]bool)
+	for i, value := range values {
+		if seen[value] {
+			v.report(pointerTo(pointer, keyword, strconv.Itoa(i)), "duplicate %s value %q", keyword, value)
+		}
+		seen[value] = true
+	}
+}
+
+// schemaOrBool reports a keyword value that is neither a schema nor a
+// boolean.
+func (v *validator) schemaOrBool(keyword string, value interface{}, pointer string) {
+	switch value.(type) {
+	case nil, bool, map[string]interface{}:
+	default:
+		v.report(pointerTo(pointer, keyword), "%s must be a schema or a boolean", keyword)
+	}
+}
+
+func (v *validator) link(l *Link, pointer string) {
+	if l.Rel == "" {
+		v.report(pointer, "missing rel")
+	}
+	if l.HRef == nil {
+		v.report(pointer, "missing href")
+	} else {
+		hp := pointerTo(pointer, "href")
+		for _, m := range href.FindAllString(l.HRef.href, -1) {
+			u, err := url.QueryUnescape(m[2 : len(m)-2])
+			if err != nil {
+				v.report(hp, "invalid href variable %s: %v", m, err)
+				continue
+			}
+			if !v.reference(Reference(u), hp) {
+				continue
+			}
+			parts := strings.Split(u, separator)
+			if len(parts) < 3 || parts[len(parts)-2] != "definitions" {
+				v.report(hp, "href variable %s does not point at a definition", u)
+			}
+		}
+	}
+	v.schema(l.Schema, pointerTo(pointer, "schema"))
+	v.schema(l.TargetSchema, pointerTo(pointer, "targetSchema"))
+}
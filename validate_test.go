@@ -0,0 +1,133 @@
+package schematic
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var validateTests = []struct {
+	Schema   string
+	Pointers []string
+}{
+	{
+		Schema: `{
+			"definitions": {"app": {"type": "object", "definitions": {"id": {"type": "string"}},
+				"links": [{"title": "Info", "rel": "self", "href": "/apps/{(%23%2Fdefinitions%2Fapp%2Fdefinitions%2Fid)}"}]}},
+			"properties": {"app": {"$ref": "#/definitions/app"}}
+		}`,
+		Pointers: nil,
+	},
+	{
+		Schema:   `{"properties": {"app": {"$ref": "#/definitions/app"}}}`,
+		Pointers: []string{"#/properties/app/$ref"},
+	},
+	{
+		Schema:   `{"properties": {"app": {"type": ["object", "strin"], "items": {"type": "list"}}}}`,
+		Pointers: []string{"#/properties/app/type/1", "#/properties/app/items/type"},
+	},
+	{
+		Schema:   `{"properties": {"app": {"type": ["object", "null"], "items": {"type": "any"}}}}`,
+		Pointers: nil,
+	},
+	{
+		Schema: `{"properties": {"app": {"links": [
+			{"title": "Info", "rel": "self", "href": "/apps"},
+			{"title": "info", "rel": "self", "href": "/apps"},
+			{"title": "List", "rel": "instances"}
+		]}}}`,
+		Pointers: []string{"#/properties/app/links/1", "#/properties/app/links/2"},
+	},
+	{
+		Schema: `{"properties": {"app": {"type": "object",
+			"properties": {"id": {"type": "string"}},
+			"links": [
+				{"title": "Info", "rel": "self", "href": "/apps/{(%23%2Fproperties%2Fapp%2Fproperties%2Fid)}"},
+				{"title": "Update", "rel": "update", "href": "/apps/{(%23%2Fdefinitions%2Fid)}", "schema": {"$ref": "#/definitions/app"}}
+			]
+		}}}`,
+		Pointers: []string{
+			"#/properties/app/links/0/href",
+			"#/properties/app/links/1/href",
+			"#/properties/app/links/1/schema/$ref",
+		},
+	},
+	{
+		Schema:   `{"properties": {"app": {"links": [{"title": "Info", "href": "/apps"}]}}}`,
+		Pointers: []string{"#/properties/app/links/0"},
+	},
+	{
+		Schema: `{"properties": {"app": {
+			"type": ["string", "null", "string"],
+			"minLength": -1,
+			"pattern": "(",
+			"enum": ["a", "b", "a"],
+			"required": [],
+			"exclusiveMaximum": true,
+			"multipleOf": -2,
+			"oneOf": [],
+			"additionalProperties": 1,
+			"dependencies": {"a": ["b", 1], "b": "c"},
+			"patternProperties": {"[": {"type": "string"}}
+		}}}`,
+		Pointers: []string{
+			"#/properties/app/multipleOf",
+			"#/properties/app/exclusiveMaximum",
+			"#/properties/app/minLength",
+			"#/properties/app/pattern",
+			"#/properties/app/patternProperties/[",
+			"#/properties/app/required",
+			"#/properties/app/enum/2",
+			"#/properties/app/type/2",
+			"#/properties/app/oneOf",
+			"#/properties/app/additionalProperties",
+			"#/properties/app/dependencies/a/1",
+			"#/properties/app/dependencies/b",
+		},
+	},
+}
+
+func TestValidate(t *testing.T) {
+	for i, vt := range validateTests {
+		var s Schema
+		if err := json.Unmarshal([]byte(vt.Schema), &s); err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		var pointers []string
+		for _, p := range s.Validate() {
+			pointers = append(pointers, p.Pointer)
+		}
+		if !reflect.DeepEqual(pointers, vt.Pointers) {
+			t.Errorf("%d: wants %v, got %v", i, vt.Pointers, pointers)
+		}
+	}
+}
+
+func TestValidateExternalDocuments(t *testing.T) {
+	loader := mapLoader{
+		"schema.json": `{"properties": {
+			"app": {"$ref": "app.json#/definitions/app"},
+			"user": {"$ref": "app.json#/definitions/user"}
+		}}`,
+		"app.json": `{"definitions": {
+			"app": {"type": "strin", "properties": {"name": {"$ref": "common.json#/definitions/name"}}},
+			"user": {"type": "object"}
+		}}`,
+		"common.json": `{"definitions": {"name": {"type": "string", "minLength": -1}}}`,
+	}
+	s, err := Load("schema.json", loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pointers []string
+	for _, p := range s.Validate() {
+		pointers = append(pointers, p.Pointer)
+	}
+	expected := []string{
+		"app.json#/definitions/app/type",
+		"common.json#/definitions/name/minLength",
+	}
+	if !reflect.DeepEqual(pointers, expected) {
+		t.Errorf("wants %v, got %v", expected, pointers)
+	}
+}
@@ -0,0 +1,295 @@
+package schematic
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// validation returns the Validate method of the request type of a link,
+// checking the constraints of its schema.
+func (g *generator) validation(name string, l *Link) (string, error) {
+	request := g.request
+	g.request = true
+	defer func() { g.request = request }()
+
+	c := &checker{g: g, rs: ResolvedSet{}}
+	if err := c.object(l.Schema, "o", fieldPath{}, false); err != nil {
+		return "", within(pointerTo(fragment, "schema"), err)
+	}
+	var buf bytes.Buffer
+	err := g.execute(&buf, "validate.tmpl", struct {
+		Name   string
+		Checks []string
+	}{
+		Name:   paramType(name, l),
+		Checks: c.stmts,
+	})
+	return buf.String(), err
+}
+
+// checker builds the statements checking a request value against the
+// constraints of its schema, reporting the broken ones to a validation v.
+type checker struct {
+	g     *generator
+	rs    ResolvedSet
+	depth int
+	stmts []string
+}
+
+func (c *checker) add(format string, a ...interface{}) {
+	c.stmts = append(c.stmts, fmt.Sprintf(format, a...))
+}
+
+// report adds the statement reporting a broken constraint of the field at
+// path p if cond holds.
+func (c *checker) report(cond string, p fieldPath, message string) {
+	c.add("if %s {\nv.add(%s, %q)\n}", cond, p, message)
+}
+
+// within adds the statements built by f inside the given block, unless
+// there are none.
+func (c *checker) within(open string, f func() error) error {
+	stmts := c.stmts
+	c.stmts = nil
+	err := f()
+	inner := c.stmts
+	c.stmts = stmts
+	if err == nil && len(inner) > 0 {
+		c.add("%s {\n%s\n}", open, strings.Join(inner, "\n"))
+	}
+	return err
+}
+
+// object adds the checks of the fields of the object held by x, found at
+// the given path p. Recursive schemas are only checked down to their first
+// repetition.
+func (c *checker) object(s *Schema, x string, p fieldPath, force bool) error {
+	if !s.isStruct() || c.rs.Has(s) {
+		return nil
+	}
+	c.rs.Insert(s)
+	defer delete(c.rs, s)
+
+	for _, name := range sortedKeys(s.Properties) {
+		prop := s.Properties[name]
+		if prop.ReadOnly {
+			continue
+		}
+		req := contains(name, s.Required) || force
+		t, err := c.g.goType(prop, req, force)
+		if err != nil {
+			return within(pointerTo(fragment, "properties", name), err)
+		}
+		fx, fp := x+"."+fieldName(name), p.field(name)
+		types, _ := prop.Types()
+		if contains(name, s.Required) && nilable(t) && !strings.HasPrefix(t, "*") && !contains("null", types) {
+			c.report(fx+" == nil", fp, "is required")
+		}
+		if err := c.value(prop, fx, fp, t, force); err != nil {
+			return within(pointerTo(fragment, "properties", name), err)
+		}
+	}
+	return nil
+}
+
+// value adds the checks of the value of Go type t held by x.
+func (c *checker) value(s *Schema, x string, p fieldPath, t string, force bool) error {
+	if strings.HasPrefix(t, "*") {
+		return c.within(fmt.Sprintf("if %s != nil", x), func() error {
+			return c.value(s, "(*"+x+")", p, t[1:], force)
+		})
+	}
+	if s.IsUnion() {
+		return nil
+	}
+	if s.IsEnum() {
+		values := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			values[i] = strconv.Quote(v)
+		}
+		c.report("!"+x+".Valid()", p, "must be one of "+strings.Join(values, ", "))
+		return nil
+	}
+
+	types, _ := s.Types()
+	switch {
+	case contains("string", types):
+		str, ok := c.g.stringValue(s, x, t)
+		if !ok {
+			return nil
+		}
+		if s.MinLength > 0 {
+			c.report(fmt.Sprintf("utf8.RuneCountInString(%s) < %d", str, s.MinLength), p,
+				"must be at least "+plural(s.MinLength, "character")+" long")
+			c.g.imports["unicode/utf8"] = true
+		}
+		if s.MaxLength > 0 {
+			c.report(fmt.Sprintf("utf8.RuneCountInString(%s) > %d", str, s.MaxLength), p,
+				"must be at most "+plural(s.MaxLength, "character")+" long")
+			c.g.imports["unicode/utf8"] = true
+		}
+		// Patterns Go can't compile are left to the server.
+		if _, err := regexp.Compile(s.Pattern); s.Pattern != "" && err == nil {
+			c.report(fmt.Sprintf("!matches(%q, %s)", s.Pattern, str), p, "must match "+s.Pattern)
+		}
+	case (contains("integer", types) || contains("number", types)) && isNumericType(t):
+		if s.Minimum != nil {
+			msg := "greater than or equal to"
+			if s.ExclusiveMinimum {
+				msg = "greater than"
+			}
+			min := strconv.FormatFloat(*s.Minimum, 'g', -1, 64)
+			if cond, ok := outOfBound(x, t, *s.Minimum, s.ExclusiveMinimum, false); ok {
+				c.report(cond, p, "must be "+msg+" "+min)
+			}
+		}
+		if s.Maximum != nil {
+			msg := "less than or equal to"
+			if s.ExclusiveMaximum {
+				msg = "less than"
+			}
+			max := strconv.FormatFloat(*s.Maximum, 'g', -1, 64)
+			if cond, ok := outOfBound(x, t, *s.Maximum, s.ExclusiveMaximum, true); ok {
+				c.report(cond, p, "must be "+msg+" "+max)
+			}
+		}
+	case contains("array", types) && strings.HasPrefix(t, "[]"):
+		// Missing arrays are reported by the required check, if any.
+		if s.MinItems > 0 {
+			c.report(fmt.Sprintf("%s != nil && len(%s) < %d", x, x, s.MinItems), p, "must have at least "+plural(s.MinItems, "item"))
+		}
+		if s.MaxItems > 0 {
+			c.report(fmt.Sprintf("len(%s) > %d", x, s.MaxItems), p, "must have at most "+plural(s.MaxItems, "item"))
+		}
+		if s.Items == nil {
+			return nil
+		}
+		c.depth++
+		defer func() { c.depth-- }()
+		i := fmt.Sprintf("i%d", c.depth)
+		return c.within(fmt.Sprintf("for %s := range %s", i, x), func() error {
+			return c.value(s.Items, x+"["+i+"]", p.index(i), t[2:], force)
+		})
+	case contains("object", types):
+		return c.object(s, x, p, force)
+	}
+	return nil
+}
+
+// stringValue returns the expression of the string held by x, of Go type t,
+// for the string schema s. Strings declared by formats.tmpl are converted,
+// types set by the options are formatted with their String method, and
+// other types, such as time.Time, are not checked.
+func (g *generator) stringValue(s *Schema, x, t string) (string, bool) {
+	ft, ok := g.lookupFormat(s.Format)
+	switch {
+	case t == "string":
+		return x, true
+	case !ok || strings.HasPrefix(t, "[]"):
+		return "", false
+	case ft.Import == "" && stringDecls[ft.Type] && t == g.runtimeType(ft.Type):
+		return "string(" + x + ")", true
+	case g.opts.Formats[s.Format] == ft && t == ft.Type:
+		return "fmt.Sprint(" + x + ")", true
+	}
+	return "", false
+}
+
+// intRanges holds the ranges of the integer types bounds are checked for.
+var intRanges = map[string][2]*big.Int{
+	"int":    {big.NewInt(math.MinInt64), big.NewInt(math.MaxInt64)},
+	"int32":  {big.NewInt(math.MinInt32), big.NewInt(math.MaxInt32)},
+	"int64":  {big.NewInt(math.MinInt64), big.NewInt(math.MaxInt64)},
+	"uint32": {big.NewInt(0), big.NewInt(math.MaxUint32)},
+	"uint64": {big.NewInt(0), new(big.Int).SetUint64(math.MaxUint64)},
+}
+
+// outOfBound returns the condition under which x, of numeric type t, is out of
+// the given lower or upper bound. Integers are compared to the closest
+// integer within the bound, in their own type so that no precision is lost,
+// and the condition is dropped when every value of t is within the bound.
+func outOfBound(x, t string, b float64, exclusive, upper bool) (string, bool) {
+	r, ok := intRanges[t]
+	if !ok {
+		op := "<"
+		if upper {
+			op = ">"
+		}
+		if exclusive {
+			op += "="
+		}
+		return fmt.Sprintf("%s %s %s", x, op, strconv.FormatFloat(b, 'g', -1, 64)), true
+	}
+
+	n, acc := new(big.Float).SetFloat64(b).Int(nil)
+	switch {
+	case !upper && (acc == big.Below || acc == big.Exact && exclusive):
+		n.Add(n, big.NewInt(1))
+	case upper && (acc == big.Above || acc == big.Exact && exclusive):
+		n.Sub(n, big.NewInt(1))
+	}
+	switch {
+	case !upper && n.Cmp(r[0]) <= 0, upper && n.Cmp(r[1]) >= 0:
+		return "", false
+	case n.Cmp(r[0]) < 0, n.Cmp(r[1]) > 0:
+		return "true", true
+	}
+	// int may be 32-bit wide, so wider bounds are compared as int64s.
+	if r32 := intRanges["int32"]; t == "int" && (n.Cmp(r32[0]) < 0 || n.Cmp(r32[1]) > 0) {
+		x = "int64(" + x + ")"
+	}
+	op := "<"
+	if upper {
+		op = ">"
+	}
+	return fmt.Sprintf("%s %s %s", x, op, n), true
+}
+
+// isNumericType returns true if t is a predeclared numeric type, whose bounds
+// can be checked.
+func isNumericType(t string) bool {
+	return contains(t, []string{"int", "int32", "int64", "uint32", "uint64", "float64"})
+}
+
+// fieldPath is the path of a field, e.g. "tags[%d].name", formatted with the
+// index variables of the enclosing loops.
+type fieldPath struct {
+	format  string
+	indexes []string
+}
+
+// field returns the path of the field of the given name.
+func (p fieldPath) field(name string) fieldPath {
+	name = strings.Replace(name, "%", "%%", -1)
+	if p.format != "" {
+		name = p.format + "." + name
+	}
+	return fieldPath{format: name, indexes: p.indexes}
+}
+
+// index returns the path of the item at the index held by i.
+func (p fieldPath) index(i string) fieldPath {
+	indexes := append(append([]string(nil), p.indexes...), i)
+	return fieldPath{format: p.format + "[%d]", indexes: indexes}
+}
+
+// String returns the Go expression of the path.
+func (p fieldPath) String() string {
+	if len(p.indexes) == 0 {
+		return strconv.Quote(strings.Replace(p.format, "%%", "%", -1))
+	}
+	return fmt.Sprintf("fmt.Sprintf(%q, %s)", p.format, strings.Join(p.indexes, ", "))
+}
+
+// plural returns the count of the given noun, e.g. "2 items".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return strconv.Itoa(n) + " " + noun + "s"
+}